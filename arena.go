@@ -0,0 +1,577 @@
+package rbtree
+
+import (
+	"cmp"
+	"iter"
+	"math"
+	"slices"
+)
+
+// ArenaTree is an ArenaSet of untyped Items.
+type ArenaTree = ArenaSet[Item]
+
+// ArenaSet is a red-black tree whose nodes live in a single slice and
+// link to each other by int32 indices rather than pointers, with each
+// node's color packed into the top bit of its parent index.
+//
+// This suits trees with millions of items. A node has no pointers of
+// its own, so if T has none either the garbage collector does not scan
+// the tree at all, and otherwise it scans only the items. Nodes are also
+// smaller than in a Set, and deleted nodes are reused by later
+// insertions.
+//
+// ArenaSet supports the core operations of Set, but not augmentation,
+// order statistics or duplicates. Growing the slice moves the nodes,
+// which is harmless since nothing points into it; iterators hold
+// indices. An iterator to a deleted item becomes invalid, and may point
+// to an unrelated item once the node is reused.
+type ArenaSet[T any] struct {
+	// nodes[0] is a black sentinel standing for the nil children of
+	// leaves and the parent of the root, as in CLRS. Deletion uses its
+	// parent index as scratch space.
+	nodes []arenaNode[T]
+	root  int32
+	// Head of the list of free nodes, linked through their left
+	// indices. 0 if the list is empty.
+	free    int32
+	count   int
+	compare func(a, b T) int
+}
+
+type arenaNode[T any] struct {
+	item        T
+	left, right int32
+	// The index of the parent, with arenaRed set if the node is red.
+	parentColor uint32
+}
+
+const arenaRed = 1 << 31
+
+// Create a new empty arena tree.
+func NewArenaTree(compare CompareFunc) *ArenaTree {
+	return NewArenaSetFunc[Item](compare)
+}
+
+// Create a new empty arena set. compare returns 0 if a==b, <0 if a<b,
+// >0 if a>b.
+func NewArenaSetFunc[T any](compare func(a, b T) int) *ArenaSet[T] {
+	return &ArenaSet[T]{nodes: make([]arenaNode[T], 1), compare: compare}
+}
+
+// Create a new empty arena set ordered by the natural order of T.
+func NewArenaSet[T cmp.Ordered]() *ArenaSet[T] {
+	return NewArenaSetFunc(cmp.Compare[T])
+}
+
+// Return the number of elements in the tree.
+func (s *ArenaSet[T]) Len() int {
+	return s.count
+}
+
+// Make room for n more items without reallocating.
+func (s *ArenaSet[T]) Grow(n int) {
+	s.nodes = slices.Grow(s.nodes, n)
+}
+
+// Delete all the items, invalidating all iterators. The memory of the
+// nodes is kept for reuse.
+func (s *ArenaSet[T]) Clear() {
+	clear(s.nodes)
+	s.nodes = s.nodes[:1]
+	s.root, s.free, s.count = 0, 0, 0
+}
+
+// A convenience function for finding an element equal to key. Return
+// the zero value of T if not found.
+func (s *ArenaSet[T]) Get(key T) T {
+	item, _ := s.Lookup(key)
+	return item
+}
+
+// Find an element equal to key. The 2nd return value is true iff such
+// an element exists.
+func (s *ArenaSet[T]) Lookup(key T) (T, bool) {
+	i := s.FindGE(key).i
+	if i > 0 && s.compare(key, s.nodes[i].item) == 0 {
+		return s.nodes[i].item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Insert an item. If the item is already in the tree, do nothing and
+// return false. Else return true.
+func (s *ArenaSet[T]) Insert(item T) bool {
+	parent, i := int32(0), s.root
+	comp := 0
+	for i != 0 {
+		parent = i
+		comp = s.compare(item, s.nodes[i].item)
+		if comp == 0 {
+			return false
+		}
+		if comp < 0 {
+			i = s.nodes[i].left
+		} else {
+			i = s.nodes[i].right
+		}
+	}
+	z := s.alloc(item)
+	s.nodes[z].parentColor = uint32(parent) | arenaRed
+	switch {
+	case parent == 0:
+		s.root = z
+	case comp < 0:
+		s.nodes[parent].left = z
+	default:
+		s.nodes[parent].right = z
+	}
+	s.count++
+	s.insertFixup(z)
+	return true
+}
+
+// Delete an item with the given key. Return true iff the item was
+// found.
+func (s *ArenaSet[T]) DeleteWithKey(key T) bool {
+	i := s.FindGE(key).i
+	if i <= 0 || s.compare(key, s.nodes[i].item) != 0 {
+		return false
+	}
+	s.delete(i)
+	return true
+}
+
+// Delete the current item, and return an iterator to its successor.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (s *ArenaSet[T]) DeleteWithIterator(iter ArenaIterator[T]) ArenaIterator[T] {
+	if iter.set != s {
+		panic(ErrForeignIterator)
+	}
+	if iter.i <= 0 {
+		panic(ErrIteratorAtLimit)
+	}
+	next := s.successor(iter.i)
+	s.delete(iter.i)
+	return ArenaIterator[T]{s, next}
+}
+
+// Create an iterator that points to the minimum item in the tree. If
+// the tree is empty, return Limit().
+func (s *ArenaSet[T]) Min() ArenaIterator[T] {
+	return ArenaIterator[T]{s, s.minimum(s.root)}
+}
+
+// Create an iterator that points at the maximum item in the tree. If
+// the tree is empty, return NegativeLimit().
+func (s *ArenaSet[T]) Max() ArenaIterator[T] {
+	if s.root == 0 {
+		return s.NegativeLimit()
+	}
+	return ArenaIterator[T]{s, s.maximum(s.root)}
+}
+
+// Create an iterator that points beyond the maximum item in the tree.
+func (s *ArenaSet[T]) Limit() ArenaIterator[T] {
+	return ArenaIterator[T]{s, 0}
+}
+
+// Create an iterator that points before the minimum item in the tree.
+func (s *ArenaSet[T]) NegativeLimit() ArenaIterator[T] {
+	return ArenaIterator[T]{s, -1}
+}
+
+// Find the smallest element N such that N >= key, and return the
+// iterator pointing to the element. If no such element is found, return
+// Limit().
+func (s *ArenaSet[T]) FindGE(key T) ArenaIterator[T] {
+	found := int32(0)
+	for i := s.root; i != 0; {
+		if s.compare(key, s.nodes[i].item) <= 0 {
+			found = i
+			i = s.nodes[i].left
+		} else {
+			i = s.nodes[i].right
+		}
+	}
+	return ArenaIterator[T]{s, found}
+}
+
+// Find the largest element N such that N <= key, and return the
+// iterator pointing to the element. If no such element is found, return
+// NegativeLimit().
+func (s *ArenaSet[T]) FindLE(key T) ArenaIterator[T] {
+	found := int32(-1)
+	for i := s.root; i != 0; {
+		if s.compare(key, s.nodes[i].item) >= 0 {
+			found = i
+			i = s.nodes[i].right
+		} else {
+			i = s.nodes[i].left
+		}
+	}
+	return ArenaIterator[T]{s, found}
+}
+
+// Return a sequence of all items in ascending order. The loop body may
+// delete the current item.
+func (s *ArenaSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := s.minimum(s.root); i != 0; {
+			next := s.successor(i)
+			if !yield(s.nodes[i].item) {
+				return
+			}
+			i = next
+		}
+	}
+}
+
+// Return a sequence of all items in descending order. The loop body may
+// delete the current item.
+func (s *ArenaSet[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if s.root == 0 {
+			return
+		}
+		for i := s.maximum(s.root); i > 0; {
+			prev := s.predecessor(i)
+			if !yield(s.nodes[i].item) {
+				return
+			}
+			i = prev
+		}
+	}
+}
+
+// ArenaIterator allows scanning an ArenaSet in sort order.
+type ArenaIterator[T any] struct {
+	set *ArenaSet[T]
+	// Index of the current node. 0 at Limit() and -1 at
+	// NegativeLimit().
+	i int32
+}
+
+func (iter ArenaIterator[T]) Equal(iter2 ArenaIterator[T]) bool {
+	return iter.set == iter2.set && iter.i == iter2.i
+}
+
+// Check if the iterator points beyond the max element in the tree
+func (iter ArenaIterator[T]) Limit() bool {
+	return iter.i == 0
+}
+
+// Check if the iterator points before the minimum element in the tree
+func (iter ArenaIterator[T]) NegativeLimit() bool {
+	return iter.i < 0
+}
+
+// Return the current element.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (iter ArenaIterator[T]) Item() T {
+	if iter.i <= 0 {
+		panic(ErrIteratorAtLimit)
+	}
+	return iter.set.nodes[iter.i].item
+}
+
+// Create a new iterator that points to the successor of the current
+// element.
+//
+// REQUIRES: !iter.Limit()
+func (iter ArenaIterator[T]) Next() ArenaIterator[T] {
+	switch {
+	case iter.i == 0:
+		panic(ErrIteratorAtLimit)
+	case iter.i < 0:
+		return iter.set.Min()
+	}
+	return ArenaIterator[T]{iter.set, iter.set.successor(iter.i)}
+}
+
+// Create a new iterator that points to the predecessor of the current
+// element.
+//
+// REQUIRES: !iter.NegativeLimit()
+func (iter ArenaIterator[T]) Prev() ArenaIterator[T] {
+	switch {
+	case iter.i < 0:
+		panic(ErrIteratorAtLimit)
+	case iter.i == 0:
+		return iter.set.Max()
+	}
+	return ArenaIterator[T]{iter.set, iter.set.predecessor(iter.i)}
+}
+
+// Return a free node holding item, with all links 0 and colored black.
+func (s *ArenaSet[T]) alloc(item T) int32 {
+	if i := s.free; i != 0 {
+		s.free = s.nodes[i].left
+		s.nodes[i] = arenaNode[T]{item: item}
+		return i
+	}
+	if len(s.nodes) > math.MaxInt32 {
+		panic("ArenaSet cannot hold more than 2^31-1 items.")
+	}
+	s.nodes = append(s.nodes, arenaNode[T]{item: item})
+	return int32(len(s.nodes) - 1)
+}
+
+// Put node i on the free list. Clearing it drops its reference to the
+// item.
+func (s *ArenaSet[T]) release(i int32) {
+	s.nodes[i] = arenaNode[T]{left: s.free}
+	s.free = i
+}
+
+func (s *ArenaSet[T]) parent(i int32) int32 {
+	return int32(s.nodes[i].parentColor &^ arenaRed)
+}
+
+func (s *ArenaSet[T]) setParent(i, parent int32) {
+	n := &s.nodes[i]
+	n.parentColor = n.parentColor&arenaRed | uint32(parent)
+}
+
+func (s *ArenaSet[T]) isRed(i int32) bool {
+	return s.nodes[i].parentColor&arenaRed != 0
+}
+
+func (s *ArenaSet[T]) setRed(i int32, red bool) {
+	if red {
+		s.nodes[i].parentColor |= arenaRed
+	} else {
+		s.nodes[i].parentColor &^= arenaRed
+	}
+}
+
+// Return the leftmost node under i, or 0 if i is 0.
+func (s *ArenaSet[T]) minimum(i int32) int32 {
+	if i == 0 {
+		return 0
+	}
+	for s.nodes[i].left != 0 {
+		i = s.nodes[i].left
+	}
+	return i
+}
+
+// Return the rightmost node under i.
+//
+// REQUIRES: i != 0
+func (s *ArenaSet[T]) maximum(i int32) int32 {
+	for s.nodes[i].right != 0 {
+		i = s.nodes[i].right
+	}
+	return i
+}
+
+// Return the node after i, or 0 if there is none.
+func (s *ArenaSet[T]) successor(i int32) int32 {
+	if r := s.nodes[i].right; r != 0 {
+		return s.minimum(r)
+	}
+	p := s.parent(i)
+	for p != 0 && i == s.nodes[p].right {
+		i, p = p, s.parent(p)
+	}
+	return p
+}
+
+// Return the node before i, or -1 if there is none.
+func (s *ArenaSet[T]) predecessor(i int32) int32 {
+	if l := s.nodes[i].left; l != 0 {
+		return s.maximum(l)
+	}
+	p := s.parent(i)
+	for p != 0 && i == s.nodes[p].left {
+		i, p = p, s.parent(p)
+	}
+	if p == 0 {
+		return -1
+	}
+	return p
+}
+
+// Rotate left at x:
+//
+//	  X             Y
+//	A   Y   =>    X   C
+//	   B C       A B
+func (s *ArenaSet[T]) rotateLeft(x int32) {
+	y := s.nodes[x].right
+	s.nodes[x].right = s.nodes[y].left
+	if b := s.nodes[y].left; b != 0 {
+		s.setParent(b, x)
+	}
+	s.replaceChild(s.parent(x), x, y)
+	s.nodes[y].left = x
+	s.setParent(x, y)
+}
+
+// Rotate right at y:
+//
+//	   Y           X
+//	 X   C  =>   A   Y
+//	A B             B C
+func (s *ArenaSet[T]) rotateRight(y int32) {
+	x := s.nodes[y].left
+	s.nodes[y].left = s.nodes[x].right
+	if b := s.nodes[x].right; b != 0 {
+		s.setParent(b, y)
+	}
+	s.replaceChild(s.parent(y), y, x)
+	s.nodes[x].right = y
+	s.setParent(y, x)
+}
+
+// Make v take u's place as a child of parent (or as the root if parent
+// is 0), and point v's parent index at parent, even if v is the
+// sentinel.
+func (s *ArenaSet[T]) replaceChild(parent, u, v int32) {
+	switch {
+	case parent == 0:
+		s.root = v
+	case s.nodes[parent].left == u:
+		s.nodes[parent].left = v
+	default:
+		s.nodes[parent].right = v
+	}
+	s.setParent(v, parent)
+}
+
+// Restore the red-black properties after inserting the red node z.
+func (s *ArenaSet[T]) insertFixup(z int32) {
+	for s.isRed(s.parent(z)) {
+		p := s.parent(z)
+		g := s.parent(p)
+		if p == s.nodes[g].left {
+			if u := s.nodes[g].right; s.isRed(u) {
+				s.setRed(p, false)
+				s.setRed(u, false)
+				s.setRed(g, true)
+				z = g
+				continue
+			}
+			if z == s.nodes[p].right {
+				z, p = p, z
+				s.rotateLeft(z)
+			}
+			s.setRed(p, false)
+			s.setRed(g, true)
+			s.rotateRight(g)
+		} else {
+			if u := s.nodes[g].left; s.isRed(u) {
+				s.setRed(p, false)
+				s.setRed(u, false)
+				s.setRed(g, true)
+				z = g
+				continue
+			}
+			if z == s.nodes[p].left {
+				z, p = p, z
+				s.rotateRight(z)
+			}
+			s.setRed(p, false)
+			s.setRed(g, true)
+			s.rotateLeft(g)
+		}
+	}
+	s.setRed(s.root, false)
+}
+
+// Unlink node z and put it on the free list. As in CLRS, a node with two
+// children is replaced by its successor node, rather than by copying the
+// successor's item, so that other iterators stay valid.
+func (s *ArenaSet[T]) delete(z int32) {
+	removedRed := s.isRed(z)
+	var x int32 // the node that takes the removed node's place
+	switch {
+	case s.nodes[z].left == 0:
+		x = s.nodes[z].right
+		s.replaceChild(s.parent(z), z, x)
+	case s.nodes[z].right == 0:
+		x = s.nodes[z].left
+		s.replaceChild(s.parent(z), z, x)
+	default:
+		y := s.minimum(s.nodes[z].right)
+		removedRed = s.isRed(y)
+		x = s.nodes[y].right
+		if s.parent(y) == z {
+			s.setParent(x, y)
+		} else {
+			s.replaceChild(s.parent(y), y, x)
+			s.nodes[y].right = s.nodes[z].right
+			s.setParent(s.nodes[y].right, y)
+		}
+		s.replaceChild(s.parent(z), z, y)
+		s.nodes[y].left = s.nodes[z].left
+		s.setParent(s.nodes[y].left, y)
+		s.setRed(y, s.isRed(z))
+	}
+	if !removedRed {
+		s.deleteFixup(x)
+	}
+	s.setParent(0, 0)
+	s.count--
+	s.release(z)
+}
+
+// Restore the red-black properties after removing a black node from the
+// path through x, which therefore has one black too few.
+func (s *ArenaSet[T]) deleteFixup(x int32) {
+	for x != s.root && !s.isRed(x) {
+		p := s.parent(x)
+		if x == s.nodes[p].left {
+			w := s.nodes[p].right
+			if s.isRed(w) {
+				s.setRed(w, false)
+				s.setRed(p, true)
+				s.rotateLeft(p)
+				w = s.nodes[p].right
+			}
+			if !s.isRed(s.nodes[w].left) && !s.isRed(s.nodes[w].right) {
+				s.setRed(w, true)
+				x = p
+				continue
+			}
+			if !s.isRed(s.nodes[w].right) {
+				s.setRed(s.nodes[w].left, false)
+				s.setRed(w, true)
+				s.rotateRight(w)
+				w = s.nodes[p].right
+			}
+			s.setRed(w, s.isRed(p))
+			s.setRed(p, false)
+			s.setRed(s.nodes[w].right, false)
+			s.rotateLeft(p)
+		} else {
+			w := s.nodes[p].left
+			if s.isRed(w) {
+				s.setRed(w, false)
+				s.setRed(p, true)
+				s.rotateRight(p)
+				w = s.nodes[p].left
+			}
+			if !s.isRed(s.nodes[w].left) && !s.isRed(s.nodes[w].right) {
+				s.setRed(w, true)
+				x = p
+				continue
+			}
+			if !s.isRed(s.nodes[w].left) {
+				s.setRed(s.nodes[w].right, false)
+				s.setRed(w, true)
+				s.rotateLeft(w)
+				w = s.nodes[p].left
+			}
+			s.setRed(w, s.isRed(p))
+			s.setRed(p, false)
+			s.setRed(s.nodes[w].left, false)
+			s.rotateRight(p)
+		}
+		x = s.root
+	}
+	s.setRed(x, false)
+}
@@ -0,0 +1,158 @@
+package rbtree
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+// Check the links, order, coloring and count of an arena tree.
+func checkArena[T any](t *testing.T, s *ArenaSet[T]) {
+	t.Helper()
+	testAssert(t, !s.isRed(0) && s.nodes[0].left == 0 && s.nodes[0].right == 0, "sentinel")
+	testAssert(t, s.root == 0 || (!s.isRed(s.root) && s.parent(s.root) == 0), "root")
+	count := 0
+	var prev *T
+	var check func(i int32) int
+	check = func(i int32) int {
+		if i == 0 {
+			return 1
+		}
+		n := &s.nodes[i]
+		for _, c := range []int32{n.left, n.right} {
+			if c != 0 {
+				testAssert(t, s.parent(c) == i, "parent link")
+				testAssert(t, !s.isRed(i) || !s.isRed(c), "red-red")
+			}
+		}
+		lh := check(n.left)
+		if prev != nil {
+			testAssert(t, s.compare(*prev, n.item) < 0, "order")
+		}
+		prev = &n.item
+		count++
+		rh := check(n.right)
+		testAssert(t, lh == rh, "black height")
+		if !s.isRed(i) {
+			lh++
+		}
+		return lh
+	}
+	check(s.root)
+	testAssert(t, count == s.Len(), "count")
+	free := 0
+	for i := s.free; i != 0; i = s.nodes[i].left {
+		free++
+	}
+	testAssert(t, count+free == len(s.nodes)-1, "every node is in the tree or free")
+}
+
+func TestArenaRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	s := NewArenaSet[int]()
+	model := map[int]bool{}
+	for i := 0; i < 20000; i++ {
+		key := r.Intn(500)
+		if r.Intn(3) == 0 {
+			testAssert(t, s.DeleteWithKey(key) == model[key], "delete")
+			delete(model, key)
+		} else {
+			testAssert(t, s.Insert(key) == !model[key], "insert")
+			model[key] = true
+		}
+		if i%500 == 0 {
+			checkArena(t, s)
+		}
+	}
+	checkArena(t, s)
+
+	var want []int
+	for key := range model {
+		want = append(want, key)
+	}
+	sort.Ints(want)
+	testAssert(t, slices.Equal(slices.Collect(s.All()), want), "all")
+	slices.Reverse(want)
+	testAssert(t, slices.Equal(slices.Collect(s.Backward()), want), "backward")
+	testAssert(t, len(s.nodes) <= 501, "deleted nodes reused")
+}
+
+func TestArenaFind(t *testing.T) {
+	s := NewArenaSet[int]()
+	testAssert(t, s.Min().Limit() && s.Max().NegativeLimit(), "empty")
+	testAssert(t, s.FindGE(1).Limit() && s.FindLE(1).NegativeLimit(), "empty find")
+	for i := 0; i < 10; i++ {
+		s.Insert(i * 10)
+	}
+	testAssert(t, s.Get(30) == 30 && s.Get(35) == 0, "get")
+	_, ok := s.Lookup(35)
+	testAssert(t, !ok, "lookup")
+	testAssert(t, s.FindGE(35).Item() == 40 && s.FindGE(40).Item() == 40, "findge")
+	testAssert(t, s.FindLE(35).Item() == 30 && s.FindLE(30).Item() == 30, "findle")
+	testAssert(t, s.FindGE(91).Limit() && s.FindLE(-1).NegativeLimit(), "find out of range")
+	testAssert(t, s.Min().Item() == 0 && s.Max().Item() == 90, "min/max")
+
+	var got []int
+	for it := s.NegativeLimit().Next(); !it.Limit(); it = it.Next() {
+		got = append(got, it.Item())
+	}
+	testAssert(t, len(got) == 10 && got[9] == 90, "next")
+	got = got[:0]
+	for it := s.Limit().Prev(); !it.NegativeLimit(); it = it.Prev() {
+		got = append(got, it.Item())
+	}
+	testAssert(t, len(got) == 10 && got[9] == 0, "prev")
+	testAssert(t, s.Min().Prev().Equal(s.NegativeLimit()), "prev of min")
+	testAssert(t, !s.Limit().Equal(NewArenaSet[int]().Limit()), "iterators of different sets")
+	testPanics(t, func() { s.Limit().Next() }, ErrIteratorAtLimit.Error())
+	testPanics(t, func() { s.NegativeLimit().Item() }, ErrIteratorAtLimit.Error())
+}
+
+func TestArenaDeleteWhileIterating(t *testing.T) {
+	s := NewArenaSet[int]()
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	other := s.FindGE(51)
+	for it := s.Min(); !it.Limit(); {
+		if it.Item()%2 == 0 {
+			it = s.DeleteWithIterator(it)
+		} else {
+			it = it.Next()
+		}
+	}
+	testAssert(t, other.Item() == 51, "other iterators stay valid")
+	for i := range s.All() {
+		if i%3 == 0 {
+			s.DeleteWithKey(i)
+		}
+	}
+	checkArena(t, s)
+	for i := range s.All() {
+		testAssert(t, i%2 == 1 && i%3 != 0, fmt.Sprint("left ", i))
+	}
+	testPanics(t, func() { s.DeleteWithIterator(NewArenaSet[int]().Min()) }, ErrForeignIterator.Error())
+}
+
+func TestArenaClear(t *testing.T) {
+	s := NewArenaTree(func(a, b Item) int { return a.(int) - b.(int) })
+	s.Grow(100)
+	testAssert(t, cap(s.nodes) >= 101, "grow")
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	s.Clear()
+	testAssert(t, s.Len() == 0 && s.Min().Limit(), "clear")
+	testAssert(t, cap(s.nodes) >= 101, "clear keeps memory")
+	s.Insert(1)
+	checkArena(t, s)
+}
+
+func TestArenaNodeSize(t *testing.T) {
+	// An item, three links and no padding beyond alignment.
+	testAssert(t, unsafe.Sizeof(arenaNode[int64]{}) == 24, "int64 node size")
+	testAssert(t, unsafe.Sizeof(arenaNode[int32]{}) == 16, "int32 node size")
+}
@@ -0,0 +1,139 @@
+package rbtree
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicTree is an AtomicSet of untyped Items.
+type AtomicTree = AtomicSet[Item]
+
+// AtomicSet is a concurrent ordered set whose readers never block.
+//
+// The current version of the tree is a PersistentSet published through
+// an atomic pointer. Writers serialize on a mutex, build the next version
+// by path copying, and publish it with a single atomic store, so a
+// reader sees either the old version or the new one, never a tree in the
+// middle of rebalancing. Get, Lookup, FindGE, FindLE, Min, Max and
+// iteration load the current version and take no lock.
+//
+// Readers that need several operations to agree, or iterators that
+// must not observe later writes, should call Snapshot and work on the
+// returned version.
+type AtomicSet[T any] struct {
+	// Serializes writers. Readers never take it.
+	mu      sync.Mutex
+	current atomic.Pointer[PersistentSet[T]]
+}
+
+// Create a new empty atomic tree.
+func NewAtomicTree(compare CompareFunc) *AtomicTree {
+	return NewAtomicSetFunc[Item](compare)
+}
+
+// Create a new empty atomic set. compare returns 0 if a==b, <0 if a<b,
+// >0 if a>b.
+func NewAtomicSetFunc[T any](compare func(a, b T) int) *AtomicSet[T] {
+	s := &AtomicSet[T]{}
+	s.current.Store(NewPersistentSetFunc(compare))
+	return s
+}
+
+// Create a new empty atomic set ordered by the natural order of T.
+func NewAtomicSet[T cmp.Ordered]() *AtomicSet[T] {
+	return NewAtomicSetFunc(cmp.Compare[T])
+}
+
+// Return the current version of the tree in O(1) time. The result is
+// private to the caller: later writes to s do not affect it, and writes
+// to it do not affect s.
+func (s *AtomicSet[T]) Snapshot() *PersistentSet[T] {
+	return s.current.Load().Snapshot()
+}
+
+// Return the number of elements in the tree.
+func (s *AtomicSet[T]) Len() int {
+	return s.current.Load().Len()
+}
+
+// A convenience function for finding an element equal to key. Return
+// the zero value of T if not found.
+func (s *AtomicSet[T]) Get(key T) T {
+	return s.current.Load().Get(key)
+}
+
+// Find an element equal to key. The 2nd return value is true iff such
+// an element exists.
+func (s *AtomicSet[T]) Lookup(key T) (T, bool) {
+	return s.current.Load().Lookup(key)
+}
+
+// Create an iterator that points to the minimum item in the current
+// version. If the tree is empty, return Limit().
+func (s *AtomicSet[T]) Min() PersistentIterator[T] {
+	return s.current.Load().Min()
+}
+
+// Create an iterator that points at the maximum item in the current
+// version. If the tree is empty, return NegativeLimit().
+func (s *AtomicSet[T]) Max() PersistentIterator[T] {
+	return s.current.Load().Max()
+}
+
+// Find the smallest element N such that N >= key in the current
+// version. If no such element is found, return Limit().
+func (s *AtomicSet[T]) FindGE(key T) PersistentIterator[T] {
+	return s.current.Load().FindGE(key)
+}
+
+// Find the largest element N such that N <= key in the current version.
+// If no such element is found, return NegativeLimit().
+func (s *AtomicSet[T]) FindLE(key T) PersistentIterator[T] {
+	return s.current.Load().FindLE(key)
+}
+
+// Return a sequence of all items in ascending order. The sequence reads
+// the version current when iteration starts.
+func (s *AtomicSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.current.Load().All()(yield)
+	}
+}
+
+// Return a sequence of all items in descending order. The sequence reads
+// the version current when iteration starts.
+func (s *AtomicSet[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.current.Load().Backward()(yield)
+	}
+}
+
+// Insert an item. If the item is already in the tree, do nothing and
+// return false. Else return true.
+func (s *AtomicSet[T]) Insert(item T) bool {
+	var inserted bool
+	s.Update(func(next *PersistentSet[T]) { inserted = next.Insert(item) })
+	return inserted
+}
+
+// Delete an item with the given key. Return true iff the item was
+// found.
+func (s *AtomicSet[T]) DeleteWithKey(key T) bool {
+	var deleted bool
+	s.Update(func(next *PersistentSet[T]) { deleted = next.DeleteWithKey(key) })
+	return deleted
+}
+
+// Call fn with a private copy of the current version and publish the
+// result once fn returns, so that readers see all of fn's changes or
+// none of them. Writers are serialized. What is published is a snapshot
+// of next, so changes made to next after fn returns are not seen by s.
+func (s *AtomicSet[T]) Update(fn func(next *PersistentSet[T])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.current.Load().Snapshot()
+	fn(next)
+	s.current.Store(next.Snapshot())
+}
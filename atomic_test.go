@@ -0,0 +1,87 @@
+package rbtree
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestAtomicSet(t *testing.T) {
+	s := NewAtomicSet[int]()
+	testAssert(t, s.Min().Limit(), "empty min")
+	for i := 0; i < 10; i += 2 {
+		testAssert(t, s.Insert(i), "insert")
+	}
+	testAssert(t, !s.Insert(4), "insert dup")
+	testAssert(t, s.FindGE(3).Item() == 4 && s.FindLE(3).Item() == 2, "find")
+
+	snap := s.Snapshot()
+	it := s.Min()
+	testAssert(t, s.DeleteWithKey(4), "delete")
+	snap.Insert(100)
+	testAssert(t, slices.Equal(slices.Collect(s.All()), []int{0, 2, 6, 8}), "current")
+	testAssert(t, slices.Equal(slices.Collect(snap.All()), []int{0, 2, 4, 6, 8, 100}), "snapshot")
+	got := []int{}
+	for ; !it.Limit(); it = it.Next() {
+		got = append(got, it.Item())
+	}
+	testAssert(t, slices.Equal(got, []int{0, 2, 4, 6, 8}), "old iterator")
+
+	s.Update(func(next *PersistentSet[int]) {
+		next.DeleteWithKey(0)
+		next.Insert(1)
+	})
+	testAssert(t, slices.Equal(slices.Collect(s.Backward()), []int{8, 6, 2, 1}), "update")
+
+	// Keeping next past Update does not give access to the published
+	// version.
+	var kept *PersistentSet[int]
+	s.Update(func(next *PersistentSet[int]) { kept = next })
+	kept.Insert(3)
+	testAssert(t, s.Len() == 4 && kept.Len() == 5, "kept version")
+}
+
+// Run with -race. A reader sees each batch of writes all or nothing.
+func TestAtomicSetConcurrent(t *testing.T) {
+	const numOps = 2000
+	s := NewAtomicSet[int]()
+	var wg sync.WaitGroup
+	for w := 0; w < 2; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numOps; i++ {
+				// Keys come in pairs (2k, 2k+1) that are always added
+				// together.
+				key := 2 * (i*2 + w)
+				s.Update(func(next *PersistentSet[int]) {
+					next.Insert(key)
+					next.Insert(key + 1)
+				})
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numOps/20; i++ {
+				snap := s.Snapshot()
+				if snap.Len()%2 != 0 {
+					t.Errorf("torn update: %d items", snap.Len())
+				}
+				prev := -1
+				for item := range snap.All() {
+					if item <= prev || (item%2 == 1 && item != prev+1) {
+						t.Errorf("%d after %d", item, prev)
+					}
+					prev = item
+				}
+				s.Get(i)
+				s.FindGE(i)
+			}
+		}()
+	}
+	wg.Wait()
+	testAssert(t, s.Len() == 4*numOps, "len")
+}
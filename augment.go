@@ -0,0 +1,148 @@
+package rbtree
+
+import "reflect"
+
+// Augmenter maintains a summary of type S for every subtree, for example
+// the sum of some field or the maximum of a secondary key. Summaries are
+// kept up to date through insertions, deletions and rebalancing, which
+// lets Aggregate fold any key range in O(log n).
+//
+// The zero value of S stands for the summary of an empty sequence, so S
+// should be chosen such that it is; for example, a maximum over values
+// that may be negative needs an S that records whether it holds one.
+type Augmenter[T, S any] interface {
+	// Return the summary of the sequence formed by the items of left,
+	// then item, then the items of right.
+	Combine(left S, item T, right S) S
+}
+
+// AugmenterFunc adapts an ordinary function to the Augmenter
+// interface.
+type AugmenterFunc[T, S any] func(left S, item T, right S) S
+
+func (f AugmenterFunc[T, S]) Combine(left S, item T, right S) S {
+	return f(left, item, right)
+}
+
+// Maintain per-subtree summaries using aug.
+//
+// Without a node pool, the tree allocates each node together with its
+// summary.
+func WithAugmenter[T, S any](aug Augmenter[T, S]) Option[T] {
+	return func(root *Set[T]) {
+		root.augmenter = &augmentation[T, S]{aug}
+	}
+}
+
+// Return the summary of the items N of s such that lo <= N < hi. Return
+// the zero S if the range is empty.
+//
+// REQUIRES: s was created WithAugmenter, with an Augmenter whose summary
+// type is S.
+func Aggregate[S, T any](s *Set[T], lo, hi T) S {
+	if s.augmenter == nil {
+		panic("rbtree: Aggregate called on a tree without an Augmenter")
+	}
+	a, ok := s.augmenter.(*augmentation[T, S])
+	if !ok {
+		panic("rbtree: Aggregate called with a summary type other than the Augmenter's")
+	}
+	return a.aggregate(s, s.root, &lo, &hi)
+}
+
+// augmenterHook is the part of an augmentation that does not depend on
+// the summary type, and so can be held by a Set[T].
+type augmenterHook[T any] interface {
+	// Return a new node holding item, with room for a summary.
+	newNode(item T) *node[T]
+	// Recompute the summary of n from its children.
+	update(n *node[T])
+	// Zero the summary of n, which is going back to a node pool.
+	clear(n *node[T])
+	// Check if other maintains the same kind of summary, so that the
+	// two may share nodes.
+	sameAs(other augmenterHook[T]) bool
+}
+
+type augmentation[T, S any] struct {
+	aug Augmenter[T, S]
+}
+
+// A node of an augmented tree allocated together with its summary, so
+// that inserting an item takes one allocation.
+type augNode[T, S any] struct {
+	node    node[T]
+	summary S
+}
+
+// Return a pointer to the summary of n.
+func summaryOf[S, T any](n *node[T]) *S {
+	s, ok := n.aux.(*S)
+	if !ok {
+		panic("rbtree: node has no summary of the Augmenter's type")
+	}
+	return s
+}
+
+// Return the summary of the subtree n, which is the zero S if n is nil.
+func getSummary[S, T any](n *node[T]) S {
+	if n == nil {
+		var zero S
+		return zero
+	}
+	return *summaryOf[S](n)
+}
+
+func (a *augmentation[T, S]) newNode(item T) *node[T] {
+	an := &augNode[T, S]{}
+	an.node.item = item
+	an.node.aux = &an.summary
+	return &an.node
+}
+
+func (a *augmentation[T, S]) update(n *node[T]) {
+	s, ok := n.aux.(*S)
+	if !ok {
+		// The node came from a node pool, and has no summary of this
+		// type yet.
+		s = new(S)
+		n.aux = s
+	}
+	*s = a.aug.Combine(getSummary[S](n.left), n.item, getSummary[S](n.right))
+}
+
+func (a *augmentation[T, S]) clear(n *node[T]) {
+	if s, ok := n.aux.(*S); ok {
+		var zero S
+		*s = zero
+	}
+}
+
+func (a *augmentation[T, S]) sameAs(other augmenterHook[T]) bool {
+	o, ok := other.(*augmentation[T, S])
+	return ok && reflect.TypeOf(o.aug) == reflect.TypeOf(a.aug)
+}
+
+// Return the summary of the items in n's subtree that are >= *lo and <
+// *hi. A nil bound is unbounded. Once the paths to lo and hi diverge,
+// each recursion has only one bound left and takes whole subtrees on
+// the other side, so this visits O(log n) nodes.
+func (a *augmentation[T, S]) aggregate(s *Set[T], n *node[T], lo, hi *T) S {
+	for n != nil {
+		if lo == nil && hi == nil {
+			return *summaryOf[S](n)
+		}
+		if lo != nil && s.compare(n.item, *lo) < 0 {
+			n = n.right
+		} else if hi != nil && s.compare(n.item, *hi) >= 0 {
+			n = n.left
+		} else {
+			return a.aug.Combine(
+				a.aggregate(s, n.left, lo, nil),
+				n.item,
+				a.aggregate(s, n.right, nil, hi))
+		}
+	}
+	var zero S
+	return zero
+}
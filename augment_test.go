@@ -0,0 +1,117 @@
+package rbtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Sums the items of a subtree.
+func sumAugmenter(left int, item int, right int) int {
+	return left + item + right
+}
+
+// Check that every node's summary matches a recomputation from scratch.
+func checkSums(t *testing.T, n *node[int]) int {
+	if n == nil {
+		return 0
+	}
+	sum := checkSums(t, n.left) + n.item + checkSums(t, n.right)
+	if got := *summaryOf[int](n); got != sum {
+		t.Fatalf("node %d: summary %d, want %d", n.item, got, sum)
+	}
+	return sum
+}
+
+func TestAggregate(t *testing.T) {
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	testAssert(t, Aggregate[int](s, 0, 100) == 0, "empty")
+	for i := 1; i <= 10; i++ {
+		s.Insert(i)
+	}
+	testAssert(t, Aggregate[int](s, 0, 100) == 55, "all")
+	testAssert(t, Aggregate[int](s, 3, 6) == 3+4+5, "3-6")
+	testAssert(t, Aggregate[int](s, 10, 11) == 10, "10-11")
+	testAssert(t, Aggregate[int](s, 6, 3) == 0, "6-3")
+	testAssert(t, Aggregate[int](s, 11, 20) == 0, "11-20")
+}
+
+func TestAggregateRandomized(t *testing.T) {
+	const numKeys = 300
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	keys := map[int]bool{}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 3000; i++ {
+		key := r.Intn(numKeys)
+		if r.Intn(3) == 0 {
+			s.DeleteWithKey(key)
+			delete(keys, key)
+		} else {
+			s.Insert(key)
+			keys[key] = true
+		}
+		checkSums(t, s.root)
+
+		lo, hi := r.Intn(numKeys), r.Intn(numKeys)
+		want := 0
+		for k := range keys {
+			if k >= lo && k < hi {
+				want += k
+			}
+		}
+		if got := Aggregate[int](s, lo, hi); got != want {
+			t.Fatalf("Aggregate(%d, %d) = %d, want %d", lo, hi, got, want)
+		}
+	}
+}
+
+func TestAggregateWithoutAugmenter(t *testing.T) {
+	s := NewSet[int]()
+	s.Insert(1)
+	testPanics(t, func() { Aggregate[int](s, 0, 2) }, "rbtree: Aggregate called on a tree without an Augmenter")
+
+	s = NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	testPanics(t, func() { Aggregate[string](s, 0, 2) }, "rbtree: Aggregate called with a summary type other than the Augmenter's")
+}
+
+// A node whose summary has another type is caught instead of being
+// reinterpreted.
+func TestSummaryTypeChecked(t *testing.T) {
+	n := &node[int]{aux: new(string)}
+	testPanics(t, func() { summaryOf[int](n) }, "rbtree: node has no summary of the Augmenter's type")
+}
+
+func TestAugmentedNodePool(t *testing.T) {
+	pool := NewNodePool[int](4)
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)), WithNodePool(pool))
+	for i := 0; i < 10; i++ {
+		s.Insert(i)
+	}
+	testAssert(t, len(pool.slab) == 2, "nodes taken from the pool")
+	s.DeleteWithKey(3)
+	s.DeleteRange(5, 7)
+	checkSums(t, s.root)
+	testAssert(t, pool.numFree == 3, "deleted nodes freed")
+	for _, i := range []int{3, 5, 6, 20} {
+		s.Insert(i)
+	}
+	checkSums(t, s.root)
+	testAssert(t, pool.numFree == 0, "free nodes reused")
+
+	// Summaries are recycled with their nodes.
+	allocs := testing.AllocsPerRun(100, func() {
+		s.DeleteWithKey(5)
+		s.Insert(5)
+	})
+	testAssert(t, allocs == 0, "allocations")
+	checkSums(t, s.root)
+
+	// A pool shared with a tree that keeps a different summary type.
+	other := NewSet(WithAugmenter[int](AugmenterFunc[int, maxEnd[int]](func(l maxEnd[int], item int, r maxEnd[int]) maxEnd[int] {
+		return maxEnd[int]{max(l.hi, item, r.hi), true}
+	})), WithNodePool(pool))
+	s.Clear()
+	for i := 0; i < 20; i++ {
+		other.Insert(i)
+	}
+	testAssert(t, Aggregate[maxEnd[int]](other, 0, 20).hi == 19, "other summary")
+}
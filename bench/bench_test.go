@@ -0,0 +1,209 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+var sizes = []int{1_000, 100_000}
+
+// A distribution returns n keys to insert and n keys to query.
+type distribution struct {
+	name string
+	keys func(r *rand.Rand, n int) (insert, query []int)
+}
+
+var distributions = []distribution{
+	{"sequential", func(r *rand.Rand, n int) ([]int, []int) {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = i * 2
+		}
+		// Walk the whole key range in order, alternating between keys
+		// and the gaps after them.
+		query := make([]int, n)
+		for i := range query {
+			query[i] = i*2 + i%2
+		}
+		return keys, query
+	}},
+	{"random", func(r *rand.Rand, n int) ([]int, []int) {
+		return randomKeys(r, n, r.Intn), randomKeys(r, n, r.Intn)
+	}},
+	{"zipfian", func(r *rand.Rand, n int) ([]int, []int) {
+		// A few keys are hot, and most are rarely used.
+		z := rand.NewZipf(r, 1.1, 1, uint64(n*4))
+		next := func(int) int { return int(z.Uint64()) }
+		return randomKeys(r, n, next), randomKeys(r, n, next)
+	}},
+}
+
+func randomKeys(r *rand.Rand, n int, next func(max int) int) []int {
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = next(n * 4)
+	}
+	return keys
+}
+
+// Run bench for every implementation, distribution and size.
+func run(b *testing.B, bench func(b *testing.B, impl implementation, insert, query []int)) {
+	for _, impl := range implementations {
+		for _, dist := range distributions {
+			for _, n := range sizes {
+				insert, query := dist.keys(rand.New(rand.NewSource(0)), n)
+				b.Run(fmt.Sprintf("%s/%s/n=%d", impl.name, dist.name, n), func(b *testing.B) {
+					b.ReportAllocs()
+					bench(b, impl, insert, query)
+				})
+			}
+		}
+	}
+}
+
+func fill(impl implementation, keys []int) orderedSet {
+	s := impl.new()
+	for _, k := range keys {
+		s.Insert(k)
+	}
+	return s
+}
+
+// Prevents the compiler from optimizing away the operations measured.
+var sink int
+
+func BenchmarkInsert(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, _ []int) {
+		var s orderedSet
+		for i := 0; i < b.N; i++ {
+			// Measure inserts into a set that grows up to n keys.
+			if i%len(insert) == 0 {
+				b.StopTimer()
+				s = impl.new()
+				b.StartTimer()
+			}
+			s.Insert(insert[i%len(insert)])
+		}
+	})
+}
+
+func BenchmarkGet(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, query []int) {
+		s := fill(impl, insert)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if s.Get(query[i%len(query)]) {
+				sink++
+			}
+		}
+	})
+}
+
+func BenchmarkFindGE(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, query []int) {
+		s := fill(impl, insert)
+		s.FindGE(0) // let mapSet sort its keys outside the timer
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			k, _ := s.FindGE(query[i%len(query)])
+			sink += k
+		}
+	})
+}
+
+func BenchmarkFindLE(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, query []int) {
+		s := fill(impl, insert)
+		s.FindLE(0)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			k, _ := s.FindLE(query[i%len(query)])
+			sink += k
+		}
+	})
+}
+
+func BenchmarkDeleteWithKey(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, _ []int) {
+		var s orderedSet
+		for i := 0; i < b.N; i++ {
+			// Measure deletes from a set that shrinks from n keys.
+			if i%len(insert) == 0 {
+				b.StopTimer()
+				s = fill(impl, insert)
+				b.StartTimer()
+			}
+			s.DeleteWithKey(insert[i%len(insert)])
+		}
+	})
+}
+
+// Replace one key with another in a set that stays at about n keys, as
+// in a cache or a sliding window. This is where recycling deleted nodes
+// pays off.
+func BenchmarkChurn(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, query []int) {
+		// The loop swaps keys between the two slices.
+		insert, query = slices.Clone(insert), slices.Clone(query)
+		s := fill(impl, insert)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			s.DeleteWithKey(insert[i%len(insert)])
+			s.Insert(query[i%len(query)])
+			insert[i%len(insert)], query[i%len(query)] = query[i%len(query)], insert[i%len(insert)]
+		}
+	})
+}
+
+// Scan all keys in order. For the map, this includes sorting the keys
+// after every change, which is what a map user pays to iterate in order
+// after updates.
+func BenchmarkScan(b *testing.B) {
+	run(b, func(b *testing.B, impl implementation, insert, _ []int) {
+		s := fill(impl, insert)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if m, ok := s.(*mapSet); ok {
+				m.dirty = true
+			}
+			sink += s.Scan()
+		}
+	})
+}
+
+// Check that the implementations agree, so that the benchmarks compare
+// like with like.
+func TestImplementationsAgree(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	sets := make([]orderedSet, len(implementations))
+	for i, impl := range implementations {
+		sets[i] = impl.new()
+	}
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(1000)
+		op := r.Intn(5)
+		var want string
+		for j, s := range sets {
+			var got string
+			switch op {
+			case 0, 1:
+				s.Insert(key)
+			case 2:
+				s.DeleteWithKey(key)
+			case 3:
+				k, ok := s.FindGE(key)
+				got = fmt.Sprint(k, ok, s.Get(key))
+			case 4:
+				k, ok := s.FindLE(key)
+				got = fmt.Sprint(k, ok, s.Scan())
+			}
+			if j == 0 {
+				want = got
+			} else if got != want {
+				t.Fatalf("op %d key %d: %s got %q, tree got %q", op, key, implementations[j].name, got, want)
+			}
+		}
+	}
+}
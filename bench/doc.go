@@ -0,0 +1,16 @@
+// Package bench compares the performance of rbtree.Set, with and without
+// a NodePool, the interface{}-based rbtree.Tree, which shows the cost of
+// the boxing that generics avoid, and rbtree.ArenaSet against a sorted
+// slice searched with binary search and a built-in map whose keys are
+// sorted on demand. It contains only benchmarks; run them with
+//
+//	go test -bench . github.com/yasushi-saito/rbtree/bench
+//
+// Each benchmark is named Operation/implementation/distribution/n=size,
+// so that, for example, -bench 'FindGE/.*/random' compares the
+// implementations on random keys.
+//
+// BenchmarkMemory compares the tree layouts: it reports the heap
+// bytes each holds per item and how long a full garbage collection takes
+// while the tree is live.
+package bench
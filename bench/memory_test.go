@@ -0,0 +1,49 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// Compare the memory layouts of the trees. Each iteration runs a full
+// garbage collection while a tree of n random keys is live, so ns/op is
+// the cost of a collection. B/item is the heap held by the tree per key,
+// and pause-ns/GC the stop-the-world time per collection.
+func BenchmarkMemory(b *testing.B) {
+	for _, name := range []string{"tree", "pooledtree", "boxedtree", "arena"} {
+		impl := findImplementation(name)
+		for _, n := range []int{100_000, 1_000_000} {
+			keys := rand.New(rand.NewSource(0)).Perm(n)
+			b.Run(fmt.Sprintf("%s/n=%d", name, n), func(b *testing.B) {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				s := fill(impl, keys)
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				perItem := float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)) / float64(n)
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					runtime.GC()
+				}
+				b.StopTimer()
+				b.ReportMetric(perItem, "B/item")
+				runtime.ReadMemStats(&before)
+				b.ReportMetric(float64(before.PauseTotalNs-after.PauseTotalNs)/float64(b.N), "pause-ns/GC")
+				runtime.KeepAlive(s)
+			})
+		}
+	}
+}
+
+func findImplementation(name string) implementation {
+	for _, impl := range implementations {
+		if impl.name == name {
+			return impl
+		}
+	}
+	panic("no implementation " + name)
+}
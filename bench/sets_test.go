@@ -0,0 +1,264 @@
+package bench
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/yasushi-saito/rbtree"
+)
+
+// The operations under test, implemented by each contender.
+type orderedSet interface {
+	Insert(key int)
+	Get(key int) bool
+	// Return the smallest key >= key.
+	FindGE(key int) (int, bool)
+	// Return the largest key <= key.
+	FindLE(key int) (int, bool)
+	DeleteWithKey(key int)
+	// Visit all keys in ascending order and return their sum.
+	Scan() int
+}
+
+type implementation struct {
+	name string
+	new  func() orderedSet
+}
+
+var implementations = []implementation{
+	{"tree", func() orderedSet { return treeSet{rbtree.NewSet[int]()} }},
+	{"pooledtree", func() orderedSet {
+		return treeSet{rbtree.NewSet(rbtree.WithNodePool(rbtree.NewNodePool[int](0)))}
+	}},
+	{"boxedtree", func() orderedSet {
+		return boxedTreeSet{rbtree.NewTree(func(a, b rbtree.Item) int { return a.(int) - b.(int) })}
+	}},
+	{"arena", func() orderedSet { return arenaSet{rbtree.NewArenaSet[int]()} }},
+	{"slice", func() orderedSet { return &sliceSet{} }},
+	{"map", func() orderedSet { return &mapSet{keys: map[int]struct{}{}} }},
+}
+
+type treeSet struct {
+	s *rbtree.Set[int]
+}
+
+func (t treeSet) Insert(key int)        { t.s.Insert(key) }
+func (t treeSet) DeleteWithKey(key int) { t.s.DeleteWithKey(key) }
+
+func (t treeSet) Get(key int) bool {
+	_, ok := t.s.Lookup(key)
+	return ok
+}
+
+func (t treeSet) FindGE(key int) (int, bool) {
+	it := t.s.FindGE(key)
+	if it.Limit() {
+		return 0, false
+	}
+	return it.Item(), true
+}
+
+func (t treeSet) FindLE(key int) (int, bool) {
+	it := t.s.FindLE(key)
+	if it.NegativeLimit() {
+		return 0, false
+	}
+	return it.Item(), true
+}
+
+func (t treeSet) Scan() int {
+	sum := 0
+	for key := range t.s.All() {
+		sum += key
+	}
+	return sum
+}
+
+// The interface{}-based Tree, which boxes every key and calls the
+// comparison function through an interface conversion.
+type boxedTreeSet struct {
+	t *rbtree.Tree
+}
+
+func (b boxedTreeSet) Insert(key int)        { b.t.Insert(key) }
+func (b boxedTreeSet) DeleteWithKey(key int) { b.t.DeleteWithKey(key) }
+
+func (b boxedTreeSet) Get(key int) bool {
+	_, ok := b.t.Lookup(key)
+	return ok
+}
+
+func (b boxedTreeSet) FindGE(key int) (int, bool) {
+	it := b.t.FindGE(key)
+	if it.Limit() {
+		return 0, false
+	}
+	return it.Item().(int), true
+}
+
+func (b boxedTreeSet) FindLE(key int) (int, bool) {
+	it := b.t.FindLE(key)
+	if it.NegativeLimit() {
+		return 0, false
+	}
+	return it.Item().(int), true
+}
+
+func (b boxedTreeSet) Scan() int {
+	sum := 0
+	for key := range b.t.All() {
+		sum += key.(int)
+	}
+	return sum
+}
+
+type arenaSet struct {
+	s *rbtree.ArenaSet[int]
+}
+
+func (a arenaSet) Insert(key int)        { a.s.Insert(key) }
+func (a arenaSet) DeleteWithKey(key int) { a.s.DeleteWithKey(key) }
+
+func (a arenaSet) Get(key int) bool {
+	_, ok := a.s.Lookup(key)
+	return ok
+}
+
+func (a arenaSet) FindGE(key int) (int, bool) {
+	it := a.s.FindGE(key)
+	if it.Limit() {
+		return 0, false
+	}
+	return it.Item(), true
+}
+
+func (a arenaSet) FindLE(key int) (int, bool) {
+	it := a.s.FindLE(key)
+	if it.NegativeLimit() {
+		return 0, false
+	}
+	return it.Item(), true
+}
+
+func (a arenaSet) Scan() int {
+	sum := 0
+	for key := range a.s.All() {
+		sum += key
+	}
+	return sum
+}
+
+// A sorted slice of distinct keys.
+type sliceSet struct {
+	keys []int
+}
+
+func (s *sliceSet) Insert(key int) {
+	i, found := slices.BinarySearch(s.keys, key)
+	if !found {
+		s.keys = slices.Insert(s.keys, i, key)
+	}
+}
+
+func (s *sliceSet) DeleteWithKey(key int) {
+	if i, found := slices.BinarySearch(s.keys, key); found {
+		s.keys = slices.Delete(s.keys, i, i+1)
+	}
+}
+
+func (s *sliceSet) Get(key int) bool {
+	_, found := slices.BinarySearch(s.keys, key)
+	return found
+}
+
+func (s *sliceSet) FindGE(key int) (int, bool) {
+	i, _ := slices.BinarySearch(s.keys, key)
+	if i == len(s.keys) {
+		return 0, false
+	}
+	return s.keys[i], true
+}
+
+func (s *sliceSet) FindLE(key int) (int, bool) {
+	i, found := slices.BinarySearch(s.keys, key)
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return 0, false
+	}
+	return s.keys[i], true
+}
+
+func (s *sliceSet) Scan() int {
+	sum := 0
+	for _, key := range s.keys {
+		sum += key
+	}
+	return sum
+}
+
+// A map for point operations, plus a sorted copy of its keys that is
+// rebuilt by the first ordered operation after a change.
+type mapSet struct {
+	keys   map[int]struct{}
+	sorted []int
+	dirty  bool
+}
+
+func (m *mapSet) Insert(key int) {
+	if _, ok := m.keys[key]; !ok {
+		m.keys[key] = struct{}{}
+		m.dirty = true
+	}
+}
+
+func (m *mapSet) DeleteWithKey(key int) {
+	if _, ok := m.keys[key]; ok {
+		delete(m.keys, key)
+		m.dirty = true
+	}
+}
+
+func (m *mapSet) Get(key int) bool {
+	_, ok := m.keys[key]
+	return ok
+}
+
+func (m *mapSet) sortedKeys() []int {
+	if m.dirty {
+		m.sorted = m.sorted[:0]
+		for key := range m.keys {
+			m.sorted = append(m.sorted, key)
+		}
+		sort.Ints(m.sorted)
+		m.dirty = false
+	}
+	return m.sorted
+}
+
+func (m *mapSet) FindGE(key int) (int, bool) {
+	keys := m.sortedKeys()
+	i := sort.SearchInts(keys, key)
+	if i == len(keys) {
+		return 0, false
+	}
+	return keys[i], true
+}
+
+func (m *mapSet) FindLE(key int) (int, bool) {
+	keys := m.sortedKeys()
+	i := sort.SearchInts(keys, key+1) - 1
+	if i < 0 {
+		return 0, false
+	}
+	return keys[i], true
+}
+
+func (m *mapSet) Scan() int {
+	sum := 0
+	for _, key := range m.sortedKeys() {
+		sum += key
+	}
+	return sum
+}
@@ -0,0 +1,109 @@
+package rbtree
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// Create a tree holding items, which must be sorted in strictly
+// increasing order (non-decreasing if opts include WithDuplicates).
+// This takes O(n) time, compared to O(n log n) for repeated Insert.
+func NewTreeFromSorted(compare CompareFunc, items []Item, opts ...Option[Item]) (*Tree, error) {
+	return NewSetFromSorted[Item](compare, items, opts...)
+}
+
+// Like NewTreeFromSorted, but read the items from seq.
+func NewTreeFromSortedSeq(compare CompareFunc, seq iter.Seq[Item], opts ...Option[Item]) (*Tree, error) {
+	return NewSetFromSortedSeq[Item](compare, seq, opts...)
+}
+
+// Create a set holding items, which must be sorted. See
+// NewTreeFromSorted.
+func NewSetFromSorted[T any](compare func(a, b T) int, items []T, opts ...Option[T]) (*Set[T], error) {
+	return NewSetFromSortedSeq(compare, slices.Values(items), opts...)
+}
+
+// Create a set holding the items read from seq, which must be sorted.
+// See NewTreeFromSorted.
+func NewSetFromSortedSeq[T any](compare func(a, b T) int, seq iter.Seq[T], opts ...Option[T]) (*Set[T], error) {
+	root := NewSetFunc(compare, opts...)
+	if err := root.buildFromSorted(seq); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Replace the contents of the (empty) tree with the items from seq.
+//
+// The items are first strung into a list of nodes through their right
+// pointers. The list is then turned into a tree whose left and right
+// subtrees differ in size by at most one at every node, so every nil
+// link is at depth h or h+1, where h = floor(log2(n)). Coloring the
+// nodes at depth h red and all others black then yields a valid
+// red-black tree.
+func (root *Set[T]) buildFromSorted(seq iter.Seq[T]) error {
+	doAssert(root.count == 0)
+	var head, tail *node[T]
+	n := 0
+	for item := range seq {
+		if tail != nil {
+			comp := root.compare(tail.item, item)
+			if comp > 0 || (comp == 0 && !root.multi) {
+				for head != nil {
+					next := head.right
+					root.freeNode(head)
+					head = next
+				}
+				return fmt.Errorf("rbtree: sorted input out of order at item %d", n)
+			}
+		}
+		nd := root.newNode(item)
+		if tail == nil {
+			head = nd
+		} else {
+			tail.right = nd
+		}
+		tail = nd
+		n++
+	}
+	root.buildFromList(head, n)
+	return nil
+}
+
+// Make the list of n nodes at head, linked through their right
+// pointers, the contents of the tree.
+func (root *Set[T]) buildFromList(head *node[T], n int) {
+	redDepth := bits.Len(uint(n)) - 1
+	if redDepth == 0 {
+		redDepth = -1 // a lone root must be black
+	}
+	root.setRoot(root.buildSubtree(&head, n, 0, redDepth))
+}
+
+// Build a subtree out of the first n nodes of the list at *head, and
+// advance *head past them.
+func (root *Set[T]) buildSubtree(head **node[T], n, depth, redDepth int) *node[T] {
+	if n == 0 {
+		return nil
+	}
+	leftSize := (n - 1) / 2
+	left := root.buildSubtree(head, leftSize, depth+1, redDepth)
+	nd := *head
+	*head = nd.right
+	nd.left = left
+	nd.right = root.buildSubtree(head, n-1-leftSize, depth+1, redDepth)
+	if nd.left != nil {
+		nd.left.parent = nd
+	}
+	if nd.right != nil {
+		nd.right.parent = nd
+	}
+	nd.color = black
+	if depth == redDepth {
+		nd.color = red
+	}
+	root.update(nd)
+	return nd
+}
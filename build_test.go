@@ -0,0 +1,76 @@
+package rbtree
+
+import (
+	"slices"
+	"testing"
+)
+
+// Check the red-black properties of the subtree at n and return its
+// black height.
+func checkRedBlack[T any](t *testing.T, n *node[T]) int {
+	if n == nil {
+		return 1
+	}
+	if n.color == red && (getColor(n.left) == red || getColor(n.right) == red) {
+		t.Fatalf("red node %v has a red child", n.item)
+	}
+	lh := checkRedBlack(t, n.left)
+	rh := checkRedBlack(t, n.right)
+	if lh != rh {
+		t.Fatalf("node %v: black heights %d and %d differ", n.item, lh, rh)
+	}
+	if n.color == black {
+		lh++
+	}
+	return lh
+}
+
+func TestNewTreeFromSorted(t *testing.T) {
+	for n := 0; n < 70; n++ {
+		items := make([]Item, n)
+		for i := range items {
+			items[i] = i * 2
+		}
+		tree, err := NewTreeFromSorted(testNewIntSet().compare, items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testAssert(t, tree.Len() == n, "len")
+		testAssert(t, getColor(tree.root) == black, "root color")
+		checkRedBlack(t, tree.root)
+		checkSizes(t, tree.root)
+		o := newOracle()
+		for i := 0; i < n; i++ {
+			o.Insert(i * 2)
+		}
+		compareContentsFull(t, o, tree)
+		for i := 0; i < n; i += 3 {
+			testAssert(t, tree.DeleteWithKey(i*2), "delete")
+			o.Delete(i * 2)
+		}
+		testAssert(t, tree.Insert(2*n+1), "insert")
+		o.Insert(2*n + 1)
+		compareContentsFull(t, o, tree)
+		checkRedBlack(t, tree.root)
+	}
+}
+
+func TestNewSetFromSortedUnsorted(t *testing.T) {
+	_, err := NewSetFromSorted(NewSet[int]().compare, []int{1, 3, 2})
+	testAssert(t, err != nil, "unsorted")
+	_, err = NewSetFromSorted(NewSet[int]().compare, []int{1, 2, 2})
+	testAssert(t, err != nil, "duplicate")
+	s, err := NewSetFromSorted(NewSet[int]().compare, []int{1, 2, 2}, WithDuplicates[int]())
+	testAssert(t, err == nil && s.Count(2) == 2, "duplicate allowed")
+}
+
+func TestNewSetFromSortedSeq(t *testing.T) {
+	s, err := NewSetFromSortedSeq(NewSet[int]().compare, slices.Values([]int{1, 2, 3, 4, 5}),
+		WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSums(t, s.root)
+	testAssert(t, Aggregate[int](s, 2, 5) == 9, "aggregate")
+	testAssert(t, s.Min().Item() == 1 && s.Max().Item() == 5, "min/max")
+}
@@ -0,0 +1,68 @@
+package rbtree
+
+// Color of a node that has been deleted from its tree.
+const tombstone = 2
+
+// Return an option that makes iterators detect when they have been
+// invalidated, and panic instead of silently reading unrelated items.
+// Item, Next, Prev and DeleteWithIterator then check that the item
+// the iterator points to has not been deleted, and that the tree has not
+// been emptied by Split, Join, a set operation, Clear or decoding since
+// the iterator was created. The checks take O(1) time per call.
+func WithIteratorChecks[T any]() Option[T] {
+	return func(root *Set[T]) {
+		root.checkIterators = true
+	}
+}
+
+// Return an option that makes the map's iterators detect when they have
+// been invalidated. See WithIteratorChecks.
+func WithMapIteratorChecks[K, V any]() MapOption[K, V] {
+	return MapOption[K, V](WithIteratorChecks[mapEntry[K, V]]())
+}
+
+// Create an iterator that points to n.
+func (root *Set[T]) iterator(n *node[T]) SetIterator[T] {
+	iter := SetIterator[T]{root: root, node: n, gen: root.generation}
+	if n != nil {
+		iter.reuses = n.reuses
+	}
+	return iter
+}
+
+// Invalidate all the iterators into the tree.
+func (root *Set[T]) invalidateIterators() {
+	root.generation++
+}
+
+// Return an error wrapping ErrStaleIterator if iterator checks are
+// enabled and the iterator is no longer valid.
+func (iter SetIterator[T]) validate() error {
+	if !iter.root.checkIterators {
+		return nil
+	}
+	if iter.gen != iter.root.generation {
+		return errTreeChanged
+	}
+	if iter.node != nil && (iter.node.color == tombstone || iter.node.reuses != iter.reuses) {
+		return errItemDeleted
+	}
+	return nil
+}
+
+// Panic if iterator checks are enabled and the iterator is no longer
+// valid.
+func (iter SetIterator[T]) checkValid() {
+	if err := iter.validate(); err != nil {
+		panic(err)
+	}
+}
+
+// Mark every node in the detached subtree n as deleted.
+func tombstoneSubtree[T any](n *node[T]) {
+	for n != nil {
+		tombstoneSubtree(n.left)
+		n.color = tombstone
+		n = n.right
+	}
+}
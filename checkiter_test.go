@@ -0,0 +1,90 @@
+package rbtree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Check that fn panics with a value whose text contains want.
+func testPanics(t *testing.T, fn func(), want string) {
+	t.Helper()
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, want) {
+			t.Fatalf("got panic %q, want %q", msg, want)
+		}
+	}()
+	fn()
+}
+
+func newCheckedSet(n int) *Set[int] {
+	s := NewSet(WithIteratorChecks[int]())
+	for i := 0; i < n; i++ {
+		s.Insert(i)
+	}
+	return s
+}
+
+func TestIteratorChecksDelete(t *testing.T) {
+	s := newCheckedSet(100)
+	// 31 has two children in a tree built by sequential inserts, so
+	// deleting it swaps it with its predecessor first.
+	it := s.FindGE(31)
+	testAssert(t, it.node.left != nil && it.node.right != nil, "two children")
+	pred, succ := it.Prev(), it.Next()
+	s.DeleteWithKey(31)
+	testPanics(t, func() { it.Item() }, "deleted")
+	testPanics(t, func() { it.Next() }, "deleted")
+	testPanics(t, func() { it.Prev() }, "deleted")
+	testPanics(t, func() { s.DeleteWithIterator(it) }, "deleted")
+	// Iterators to other items are unaffected.
+	testAssert(t, pred.Item() == 30 && pred.Next().Item() == 32, "pred")
+	testAssert(t, succ.Item() == 32 && succ.Prev().Item() == 30, "succ")
+	testAssert(t, s.Limit().Prev().Item() == 99, "limit")
+
+	s.DeleteRange(10, 20)
+	testPanics(t, func() { it.Item() }, "deleted")
+	testAssert(t, pred.Item() == 30, "after DeleteRange")
+	odd := s.FindGE(41)
+	s.DeleteIf(func(i int) bool { return i%2 == 0 })
+	testPanics(t, func() { pred.Item() }, "deleted")
+	testAssert(t, odd.Prev().Item() == 39, "after DeleteIf")
+}
+
+func TestIteratorChecksSplit(t *testing.T) {
+	s := newCheckedSet(10)
+	it := s.Min()
+	left, right := s.Split(5)
+	testPanics(t, func() { it.Item() }, "split")
+	testAssert(t, left.checkIterators && right.checkIterators, "option copied")
+
+	lit := left.Max()
+	Join(left, 100, newCheckedSet(0))
+	testPanics(t, func() { lit.Next() }, "split")
+}
+
+func TestIteratorChecksNodePool(t *testing.T) {
+	pool := NewNodePool[int](4)
+	s := NewSet(WithNodePool(pool), WithIteratorChecks[int]())
+	for i := 0; i < 10; i++ {
+		s.Insert(i)
+	}
+	it := s.FindGE(5)
+	s.DeleteWithKey(5)
+	testPanics(t, func() { it.Item() }, "deleted")
+	// The node is reused for an unrelated item.
+	s.Insert(100)
+	testAssert(t, pool.numFree == 0, "node reused")
+	testPanics(t, func() { it.Item() }, "deleted")
+	testPanics(t, func() { it.Next() }, "deleted")
+	testAssert(t, s.FindGE(100).Item() == 100, "fresh iterator")
+}
+
+func TestIteratorChecksDisabled(t *testing.T) {
+	s := NewSet[int]()
+	s.Insert(1)
+	it := s.Min()
+	s.DeleteWithKey(1)
+	it.Item() // stale, but not checked
+}
@@ -0,0 +1,93 @@
+package rbtree
+
+// Delete the items N such that lo <= N < hi, and return how many were
+// deleted. The range is cut out with two splits and a concatenation,
+// each O(log n) since join is given the black heights of its inputs, so
+// this takes O(log n) time however many items it deletes, or O(log n + k)
+// with WithIteratorChecks or WithNodePool, which visit the k deleted
+// nodes.
+//
+// Iterators to the deleted items become invalid.
+func (root *Set[T]) DeleteRange(lo, hi T) int {
+	if root.compare(lo, hi) >= 0 {
+		return 0
+	}
+	l, rest := root.split(measure(root.root), lo)
+	mid, r := root.split(rest, hi)
+	root.setRoot(root.concat(l, r).n)
+	deleted := getSize(mid.n)
+	if root.pool != nil {
+		root.freeSubtree(mid.n)
+	} else if root.checkIterators {
+		tombstoneSubtree(mid.n)
+	}
+	return deleted
+}
+
+// Delete the items for which pred returns true, and return how many
+// were deleted. This visits every item once and relinks the survivors
+// into a balanced tree, taking O(n) time. Iterators to the surviving
+// items remain valid.
+//
+// pred is called on every item before the tree is changed, so if it
+// panics the tree is left as it was.
+//
+// REQUIRES: pred does not modify the tree.
+func (root *Set[T]) DeleteIf(pred func(item T) bool) int {
+	drop := make([]bool, 0, root.count)
+	deleted := 0
+	for n := root.minNode; n != nil; n = n.doNext() {
+		d := pred(n.item)
+		drop = append(drop, d)
+		if d {
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0
+	}
+
+	var head, tail *node[T]
+	i := 0
+	var visit func(n *node[T])
+	visit = func(n *node[T]) {
+		if n == nil {
+			return
+		}
+		// Read the children first, since linking n's successor into
+		// the list overwrites n.right.
+		left, right := n.left, n.right
+		visit(left)
+		if drop[i] {
+			n.color = tombstone
+			root.freeNode(n)
+		} else {
+			if tail == nil {
+				head = n
+			} else {
+				tail.right = n
+			}
+			tail = n
+		}
+		i++
+		visit(right)
+	}
+	visit(root.root)
+	if tail != nil {
+		tail.right = nil
+	}
+	root.buildFromList(head, root.count-deleted)
+	return deleted
+}
+
+// Delete the entries whose keys K satisfy lo <= K < hi, and return how
+// many were deleted. See Set.DeleteRange.
+func (m *Map[K, V]) DeleteRange(lo, hi K) int {
+	return m.tree.DeleteRange(mapEntry[K, V]{key: lo}, mapEntry[K, V]{key: hi})
+}
+
+// Delete the entries for which pred returns true, and return how many
+// were deleted. See Set.DeleteIf.
+func (m *Map[K, V]) DeleteIf(pred func(key K, value V) bool) int {
+	return m.tree.DeleteIf(func(e mapEntry[K, V]) bool { return pred(e.key, e.value) })
+}
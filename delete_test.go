@@ -0,0 +1,90 @@
+package rbtree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestDeleteRange(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		s, keys := newRandomSumSet(r, r.Intn(200), 1000)
+		lo, hi := r.Intn(1000), r.Intn(1000)
+		before := len(keys)
+		for k := range keys {
+			if lo <= k && k < hi {
+				delete(keys, k)
+			}
+		}
+		testAssert(t, s.DeleteRange(lo, hi) == before-len(keys), "count")
+		checkSetContents(t, s, keys)
+	}
+
+	s := NewSet[int]()
+	for i := 0; i < 10; i++ {
+		s.Insert(i)
+	}
+	testAssert(t, s.DeleteRange(5, 5) == 0 && s.DeleteRange(7, 3) == 0, "empty range")
+	testAssert(t, s.DeleteRange(3, 7) == 4, "3-7")
+	testAssert(t, slices.Equal(slices.Collect(s.All()), []int{0, 1, 2, 7, 8, 9}), "contents")
+	testAssert(t, s.Min().Item() == 0 && s.Max().Item() == 9, "min/max")
+	testAssert(t, s.DeleteRange(-1, 100) == 6 && s.Len() == 0 && s.Min().Limit(), "all")
+}
+
+func TestDeleteIf(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		s, keys := newRandomSumSet(r, r.Intn(200), 1000)
+		mod := r.Intn(4) + 1
+		var it SetIterator[int]
+		if s.Len() > 0 {
+			it = s.Select(r.Intn(s.Len()))
+		}
+		before := len(keys)
+		for k := range keys {
+			if k%mod == 0 {
+				delete(keys, k)
+			}
+		}
+		testAssert(t, s.DeleteIf(func(k int) bool { return k%mod == 0 }) == before-len(keys), "count")
+		checkSetContents(t, s, keys)
+		// An iterator to a surviving item stays usable.
+		if it.node != nil && it.Item()%mod != 0 {
+			testAssert(t, s.FindGE(it.Item()).Equal(it), "iterator")
+		}
+	}
+
+	m := NewMap[int, string]()
+	for i := 0; i < 6; i++ {
+		m.Insert(i, "x")
+	}
+	m.Replace(2, "keep")
+	testAssert(t, m.DeleteIf(func(k int, v string) bool { return v == "x" && k > 1 }) == 3, "map DeleteIf")
+	testAssert(t, m.DeleteRange(0, 1) == 1 && m.Len() == 2, "map DeleteRange")
+}
+
+func TestDeleteIfPanickingPred(t *testing.T) {
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	testPanics(t, func() {
+		s.DeleteIf(func(k int) bool {
+			if k == 50 {
+				panic("boom")
+			}
+			return k%2 == 0
+		})
+	}, "boom")
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	checkSums(t, s.root)
+	testAssert(t, s.Len() == 100 && s.Min().Item() == 0 && s.Max().Item() == 99, "tree unchanged")
+	n := 0
+	for range s.All() {
+		n++
+	}
+	testAssert(t, n == 100, "walk")
+}
@@ -0,0 +1,136 @@
+package rbtree
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DrawOptions controls how WriteDOT and WriteASCII render a tree. A nil
+// *DrawOptions selects the defaults.
+type DrawOptions[T any] struct {
+	// Return the text to show for an item. If nil, items are formatted
+	// with %v.
+	Label func(item T) string
+}
+
+// Write the structure of the tree to w in Graphviz DOT format, with
+// nodes filled red or black. Nil children are drawn as points, so that
+// a lone child can be told to be a left or a right child.
+//
+//	tree.WriteDOT(f, nil)
+//	$ dot -Tpng tree.dot > tree.png
+func (root *Set[T]) WriteDOT(w io.Writer, opts *DrawOptions[T]) error {
+	d := newDrawer(w, opts)
+	d.printf("digraph rbtree {\n")
+	d.printf("\tnode [style=filled, fontcolor=white, fontname=\"Helvetica\"];\n")
+	if root.root != nil {
+		d.dot(root.root)
+	}
+	d.printf("}\n")
+	return d.err
+}
+
+// Write the structure of the tree to w as ASCII art, drawn sideways with
+// the root on the left and larger items above smaller ones. Each item
+// is followed by [R] or [B] for its color:
+//
+//	    /-- 3 [B]
+//	2 [B]
+//	    |   /-- 1 [R]
+//	    \-- 0 [B]
+func (root *Set[T]) WriteASCII(w io.Writer, opts *DrawOptions[T]) error {
+	d := newDrawer(w, opts)
+	if root.root != nil {
+		d.ascii(root.root, "", "")
+	}
+	return d.err
+}
+
+type drawer[T any] struct {
+	w     io.Writer
+	label func(item T) string
+	// The first write error.
+	err error
+	// Number of DOT node IDs used.
+	ids int
+}
+
+func newDrawer[T any](w io.Writer, opts *DrawOptions[T]) *drawer[T] {
+	d := &drawer[T]{w: w, label: func(item T) string { return fmt.Sprint(item) }}
+	if opts != nil && opts.Label != nil {
+		d.label = opts.Label
+	}
+	return d
+}
+
+func (d *drawer[T]) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+func (d *drawer[T]) newID() string {
+	d.ids++
+	return "n" + strconv.Itoa(d.ids-1)
+}
+
+// Write the DOT statements for the subtree at n, and return n's ID.
+func (d *drawer[T]) dot(n *node[T]) string {
+	id := d.newID()
+	if n == nil {
+		d.printf("\t%s [shape=point];\n", id)
+		return id
+	}
+	color := "black"
+	if n.color == red {
+		color = "red"
+	}
+	d.printf("\t%s [label=%s, fillcolor=%s];\n", id, dotQuote(d.label(n.item)), color)
+	if n.left != nil || n.right != nil {
+		d.printf("\t%s -> %s;\n", id, d.dot(n.left))
+		d.printf("\t%s -> %s;\n", id, d.dot(n.right))
+	}
+	return id
+}
+
+// Escapes for a DOT string literal, which, unlike a Go one, only knows
+// \" and \\, and \n for a centered line break in a label.
+var dotEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`)
+
+// Return s as a DOT string literal.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// Write the lines for the subtree at n. prefix is the text in front of
+// the connector, and edge is "/" if n is a right child, "\" if it is a
+// left child, and empty at the root.
+func (d *drawer[T]) ascii(n *node[T], prefix, edge string) {
+	if n.right != nil {
+		d.ascii(n.right, prefix+d.indent(edge == `\`), "/")
+	}
+	connector := ""
+	if edge != "" {
+		connector = edge + "-- "
+	}
+	color := "B"
+	if n.color == red {
+		color = "R"
+	}
+	d.printf("%s%s%s [%s]\n", prefix, connector, d.label(n.item), color)
+	if n.left != nil {
+		d.ascii(n.left, prefix+d.indent(edge == "/"), `\`)
+	}
+}
+
+// Return the indentation of a child's subtree. A vertical bar continues
+// the edge from n's parent if the child's lines fall between n and its
+// parent.
+func (d *drawer[T]) indent(bar bool) string {
+	if bar {
+		return "|   "
+	}
+	return "    "
+}
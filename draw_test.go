@@ -0,0 +1,75 @@
+package rbtree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newDrawTestSet() *Set[int] {
+	s := NewSet[int]()
+	for _, i := range []int{2, 3, 0, 1} {
+		s.Insert(i)
+	}
+	return s
+}
+
+func TestWriteASCII(t *testing.T) {
+	var b strings.Builder
+	testAssert(t, newDrawTestSet().WriteASCII(&b, nil) == nil, "write")
+	want := `    /-- 3 [B]
+2 [B]
+    |   /-- 1 [R]
+    \-- 0 [B]
+`
+	if b.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	testAssert(t, NewSet[int]().WriteASCII(&b, nil) == nil && b.Len() == 0, "empty")
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b strings.Builder
+	opts := &DrawOptions[int]{Label: func(i int) string { return fmt.Sprintf("item \"%d\"", i) }}
+	testAssert(t, newDrawTestSet().WriteDOT(&b, opts) == nil, "write")
+	want := `digraph rbtree {
+	node [style=filled, fontcolor=white, fontname="Helvetica"];
+	n0 [label="item \"2\"", fillcolor=black];
+	n1 [label="item \"0\"", fillcolor=black];
+	n2 [shape=point];
+	n1 -> n2;
+	n3 [label="item \"1\"", fillcolor=red];
+	n1 -> n3;
+	n0 -> n1;
+	n4 [label="item \"3\"", fillcolor=black];
+	n0 -> n4;
+}
+`
+	if b.String() != want {
+		t.Fatalf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteDOTEscapes(t *testing.T) {
+	s := NewSet[string]()
+	s.Insert("café \\ \"x\"\nnext")
+	var b strings.Builder
+	testAssert(t, s.WriteDOT(&b, nil) == nil, "write")
+	want := `n0 [label="café \\ \"x\"\nnext", fillcolor=black];`
+	testAssert(t, strings.Contains(b.String(), want), "escaped label")
+}
+
+func TestDrawWriteError(t *testing.T) {
+	s := newDrawTestSet()
+	testAssert(t, s.WriteDOT(failingWriter{}, nil) != nil, "DOT")
+	testAssert(t, s.WriteASCII(failingWriter{}, nil) != nil, "ASCII")
+}
@@ -0,0 +1,248 @@
+package rbtree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// Codec converts items to and from bytes when a Set is marshalled. It is
+// needed to decode a Tree from JSON, since encoding/json cannot decode
+// interface{} items back into their original types. For MarshalJSON the bytes must
+// be valid JSON; MarshalBinary and GobEncode accept any bytes.
+type Codec[T any] interface {
+	MarshalItem(item T) ([]byte, error)
+	UnmarshalItem(data []byte) (T, error)
+}
+
+// Return an option that makes the tree encode and decode its items with
+// c.
+func WithCodec[T any](c Codec[T]) Option[T] {
+	return func(root *Set[T]) {
+		root.codec = c
+	}
+}
+
+var (
+	errNoCompare = errors.New("rbtree: cannot decode into a tree that was not created by a constructor")
+	errNoCodec   = errors.New("rbtree: cannot decode JSON items of an interface type without a Codec")
+)
+
+// Encode the items in ascending order as a JSON array.
+func (root *Set[T]) MarshalJSON() ([]byte, error) {
+	if root.codec == nil {
+		return json.Marshal(root.items())
+	}
+	raw, err := root.marshalItems()
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]json.RawMessage, len(raw))
+	for i, data := range raw {
+		msgs[i] = data
+	}
+	return json.Marshal(msgs)
+}
+
+// Replace the contents of the tree with the items of a JSON array
+// written by MarshalJSON. The array must be sorted, and is loaded in
+// O(n) time.
+//
+// REQUIRES: The tree was created by a constructor, which supplies the
+// comparison function. If T is an interface type, such as Item, the
+// tree was created WithCodec.
+func (root *Set[T]) UnmarshalJSON(data []byte) error {
+	if root.codec == nil {
+		if reflect.TypeFor[T]().Kind() == reflect.Interface {
+			return errNoCodec
+		}
+		var items []T
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		return root.load(items)
+	}
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return err
+	}
+	raw := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		raw[i] = msg
+	}
+	return root.unmarshalItems(raw)
+}
+
+// Encode the items in ascending order with encoding/gob. If T is an
+// interface type and the tree has no codec, the concrete item types must
+// be registered with gob.Register.
+func (root *Set[T]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if root.codec == nil {
+		err = gob.NewEncoder(&buf).Encode(root.items())
+	} else {
+		var raw [][]byte
+		if raw, err = root.marshalItems(); err == nil {
+			err = gob.NewEncoder(&buf).Encode(raw)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Replace the contents of the tree with items written by MarshalBinary,
+// in O(n) time.
+//
+// REQUIRES: The tree was created by a constructor, which supplies the
+// comparison function.
+func (root *Set[T]) UnmarshalBinary(data []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if root.codec == nil {
+		var items []T
+		if err := dec.Decode(&items); err != nil {
+			return err
+		}
+		return root.load(items)
+	}
+	var raw [][]byte
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	return root.unmarshalItems(raw)
+}
+
+// Same as MarshalBinary.
+func (root *Set[T]) GobEncode() ([]byte, error) {
+	return root.MarshalBinary()
+}
+
+// Same as UnmarshalBinary.
+func (root *Set[T]) GobDecode(data []byte) error {
+	return root.UnmarshalBinary(data)
+}
+
+// Return all the items in ascending order.
+func (root *Set[T]) items() []T {
+	return slices.AppendSeq(make([]T, 0, root.count), root.All())
+}
+
+// Replace the contents of the tree with items, which must be sorted. If
+// they are not, return an error and leave the tree unchanged.
+func (root *Set[T]) load(items []T) error {
+	if root.compare == nil {
+		return errNoCompare
+	}
+	fresh := root.newEmpty()
+	if err := fresh.buildFromSorted(slices.Values(items)); err != nil {
+		return err
+	}
+	root.Clear()
+	root.setRoot(fresh.root)
+	return nil
+}
+
+// Encode all the items in ascending order with the codec.
+func (root *Set[T]) marshalItems() ([][]byte, error) {
+	raw := make([][]byte, 0, root.count)
+	for item := range root.All() {
+		data, err := root.codec.MarshalItem(item)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, data)
+	}
+	return raw, nil
+}
+
+// Decode raw with the codec and load the result.
+func (root *Set[T]) unmarshalItems(raw [][]byte) error {
+	items := make([]T, len(raw))
+	for i, data := range raw {
+		item, err := root.codec.UnmarshalItem(data)
+		if err != nil {
+			return err
+		}
+		items[i] = item
+	}
+	return root.load(items)
+}
+
+// The encoded form of a map entry.
+type mapPair[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// Encode the entries in ascending key order as a JSON array of
+// {"key": ..., "value": ...} objects.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.pairs())
+}
+
+// Replace the contents of the map with entries written by MarshalJSON,
+// in O(n) time.
+//
+// REQUIRES: The map was created by a constructor.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	var pairs []mapPair[K, V]
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return err
+	}
+	return m.load(pairs)
+}
+
+// Encode the entries in ascending key order with encoding/gob.
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m.pairs()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Replace the contents of the map with entries written by
+// MarshalBinary, in O(n) time.
+//
+// REQUIRES: The map was created by a constructor.
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	var pairs []mapPair[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&pairs); err != nil {
+		return err
+	}
+	return m.load(pairs)
+}
+
+// Same as MarshalBinary.
+func (m *Map[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// Same as UnmarshalBinary.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	return m.UnmarshalBinary(data)
+}
+
+func (m *Map[K, V]) pairs() []mapPair[K, V] {
+	pairs := make([]mapPair[K, V], 0, m.Len())
+	for e := range m.tree.All() {
+		pairs = append(pairs, mapPair[K, V]{e.key, e.value})
+	}
+	return pairs
+}
+
+func (m *Map[K, V]) load(pairs []mapPair[K, V]) error {
+	if m.tree == nil {
+		return errNoCompare
+	}
+	entries := make([]mapEntry[K, V], len(pairs))
+	for i, p := range pairs {
+		entries[i] = mapEntry[K, V]{p.Key, p.Value}
+	}
+	return m.tree.load(entries)
+}
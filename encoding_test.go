@@ -0,0 +1,109 @@
+package rbtree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSetJSON(t *testing.T) {
+	s := NewSet[int]()
+	for _, i := range []int{5, 1, 3} {
+		s.Insert(i)
+	}
+	data, err := json.Marshal(s)
+	testAssert(t, err == nil && string(data) == "[1,3,5]", "marshal")
+
+	s2 := NewSet[int]()
+	s2.Insert(100)
+	testAssert(t, json.Unmarshal(data, s2) == nil, "unmarshal")
+	testAssert(t, slices.Equal(slices.Collect(s2.All()), []int{1, 3, 5}), "contents")
+	checkSizes(t, s2.root)
+	checkRedBlack(t, s2.root)
+
+	testAssert(t, json.Unmarshal([]byte("[3,1]"), s2) != nil, "out of order")
+	testAssert(t, slices.Equal(slices.Collect(s2.All()), []int{1, 3, 5}), "unchanged after error")
+	checkSizes(t, s2.root)
+	testAssert(t, json.Unmarshal(data, &Set[int]{}) == errNoCompare, "no compare")
+
+	empty, err := json.Marshal(NewSet[int]())
+	testAssert(t, err == nil && string(empty) == "[]", "marshal empty")
+}
+
+func TestMapJSONAndGob(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Insert("b", 2)
+	m.Insert("a", 1)
+	data, err := json.Marshal(m)
+	testAssert(t, err == nil && string(data) == `[{"key":"a","value":1},{"key":"b","value":2}]`, "marshal")
+	m2 := NewMap[string, int]()
+	testAssert(t, json.Unmarshal(data, m2) == nil, "unmarshal")
+	v, ok := m2.Get("b")
+	testAssert(t, m2.Len() == 2 && ok && v == 2, "contents")
+	testAssert(t, json.Unmarshal([]byte(`[{"key":"b"},{"key":"a"}]`), m2) != nil, "out of order")
+	v, ok = m2.Get("b")
+	testAssert(t, m2.Len() == 2 && ok && v == 2, "unchanged after error")
+
+	// A map embedded in a struct is decoded in place.
+	type wrapper struct {
+		M *Map[string, int]
+	}
+	var buf bytes.Buffer
+	testAssert(t, gob.NewEncoder(&buf).Encode(wrapper{m}) == nil, "gob encode")
+	w := wrapper{NewMap[string, int]()}
+	testAssert(t, gob.NewDecoder(&buf).Decode(&w) == nil, "gob decode")
+	v, ok = w.M.Get("a")
+	testAssert(t, w.M.Len() == 2 && ok && v == 1, "gob contents")
+}
+
+func TestSetBinary(t *testing.T) {
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	data, err := s.MarshalBinary()
+	testAssert(t, err == nil, "marshal")
+	s2 := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	testAssert(t, s2.UnmarshalBinary(data) == nil, "unmarshal")
+	testAssert(t, slices.Equal(slices.Collect(s2.All()), slices.Collect(s.All())), "contents")
+	checkSums(t, s2.root)
+}
+
+// Encodes int items of a Tree as JSON numbers.
+type intCodec struct{}
+
+func (intCodec) MarshalItem(item Item) ([]byte, error) {
+	return json.Marshal(item.(int))
+}
+
+func (intCodec) UnmarshalItem(data []byte) (Item, error) {
+	var i int
+	err := json.Unmarshal(data, &i)
+	return i, err
+}
+
+func TestTreeCodec(t *testing.T) {
+	newTree := func() *Tree {
+		return NewTree(func(a, b Item) int { return a.(int) - b.(int) }, WithCodec[Item](intCodec{}))
+	}
+	tree := newTree()
+	for i := 0; i < 10; i++ {
+		tree.Insert(i)
+	}
+	data, err := json.Marshal(tree)
+	testAssert(t, err == nil, "marshal json")
+	tree2 := newTree()
+	testAssert(t, json.Unmarshal(data, tree2) == nil, "unmarshal json")
+	testAssert(t, tree2.Len() == 10 && tree2.Get(7).(int) == 7, "json contents")
+
+	data, err = tree.GobEncode()
+	testAssert(t, err == nil, "gob encode")
+	tree3 := newTree()
+	testAssert(t, tree3.GobDecode(data) == nil, "gob decode")
+	testAssert(t, tree3.Len() == 10 && tree3.Max().Item().(int) == 9, "gob contents")
+
+	plain := NewTree(func(a, b Item) int { return a.(int) - b.(int) })
+	testAssert(t, json.Unmarshal([]byte("[1,2]"), plain) == errNoCodec, "no codec")
+}
@@ -0,0 +1,25 @@
+package rbtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the Try variants of iterator operations. The
+// corresponding panicking operations panic with the same errors, so a
+// recovered value can be tested with errors.Is.
+var (
+	// The iterator was passed to a tree it does not belong to.
+	ErrForeignIterator = errors.New("rbtree: iterator is not from this tree")
+	// The iterator is at Limit() or NegativeLimit(), so the operation
+	// has no item to act on.
+	ErrIteratorAtLimit = errors.New("rbtree: iterator is at a limit")
+	// The iterator was invalidated by a modification of the tree.
+	// Reported only by trees created with WithIteratorChecks.
+	ErrStaleIterator = errors.New("rbtree: stale iterator")
+)
+
+var (
+	errItemDeleted = fmt.Errorf("%w: its item was deleted", ErrStaleIterator)
+	errTreeChanged = fmt.Errorf("%w: the tree was split, joined, cleared or reloaded", ErrStaleIterator)
+)
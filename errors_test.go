@@ -0,0 +1,58 @@
+package rbtree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTryIteratorErrors(t *testing.T) {
+	s := NewSet(WithIteratorChecks[int]())
+	other := NewSet[int]()
+	for i := 0; i < 3; i++ {
+		s.Insert(i)
+		other.Insert(i)
+	}
+
+	_, err := s.TryDeleteWithIterator(other.Min())
+	testAssert(t, errors.Is(err, ErrForeignIterator), "foreign")
+	_, err = s.TryDeleteWithIterator(s.Limit())
+	testAssert(t, errors.Is(err, ErrIteratorAtLimit), "delete limit")
+	_, err = s.TryDeleteWithIterator(s.NegativeLimit())
+	testAssert(t, errors.Is(err, ErrIteratorAtLimit), "delete negative limit")
+	testAssert(t, s.Len() == 3, "unchanged")
+
+	_, err = s.Limit().TryNext()
+	testAssert(t, errors.Is(err, ErrIteratorAtLimit), "next at limit")
+	_, err = s.NegativeLimit().TryPrev()
+	testAssert(t, errors.Is(err, ErrIteratorAtLimit), "prev at negative limit")
+	it, err := s.NegativeLimit().TryNext()
+	testAssert(t, err == nil && it.Item() == 0, "next from negative limit")
+	it, err = s.Limit().TryPrev()
+	testAssert(t, err == nil && it.Item() == 2, "prev from limit")
+
+	stale := s.Min()
+	next, err := s.TryDeleteWithIterator(stale)
+	testAssert(t, err == nil && next.Item() == 1, "delete")
+	_, err = stale.TryNext()
+	testAssert(t, errors.Is(err, ErrStaleIterator), "stale next")
+	_, err = s.TryDeleteWithIterator(stale)
+	testAssert(t, errors.Is(err, ErrStaleIterator), "stale delete")
+
+	// The panicking variants panic with the same errors.
+	defer func() {
+		err, _ := recover().(error)
+		testAssert(t, errors.Is(err, ErrForeignIterator), "panic value")
+	}()
+	s.DeleteWithIterator(other.Min())
+}
+
+func TestMapTryIterator(t *testing.T) {
+	m := NewMap[int, string]()
+	m.Insert(1, "a")
+	_, err := m.Limit().TryNext()
+	testAssert(t, errors.Is(err, ErrIteratorAtLimit), "next")
+	_, err = m.Min().TryPrev()
+	testAssert(t, err == nil, "prev to negative limit")
+	next, err := m.TryDeleteWithIterator(m.Min())
+	testAssert(t, err == nil && next.Limit() && m.Len() == 0, "delete")
+}
@@ -0,0 +1,146 @@
+package rbtree
+
+import (
+	"slices"
+	"testing"
+)
+
+// Operations decoded from fuzzer input. Each takes two bytes: the
+// operation and a key.
+const (
+	fuzzInsert = iota
+	fuzzDeleteWithKey
+	fuzzDeleteWithIterator
+	fuzzFindGE
+	fuzzFindLE
+	fuzzNext
+	fuzzPrev
+	numFuzzOps
+)
+
+// Where the model's cursor points.
+const (
+	cursorNegativeLimit = iota
+	cursorItem
+	cursorLimit
+)
+
+// A reference model of a set with a cursor: a sorted slice of distinct
+// keys.
+type fuzzModel struct {
+	keys   []int
+	cursor int // one of the cursor* constants
+	key    int // the key the cursor points to, if cursorItem
+}
+
+// Point the cursor at keys[i], or at a limit if i is out of range.
+func (m *fuzzModel) seek(i int) {
+	switch {
+	case i < 0:
+		m.cursor = cursorNegativeLimit
+	case i >= len(m.keys):
+		m.cursor = cursorLimit
+	default:
+		m.cursor, m.key = cursorItem, m.keys[i]
+	}
+}
+
+func (m *fuzzModel) index() int {
+	i, _ := slices.BinarySearch(m.keys, m.key)
+	return i
+}
+
+// Run the operations in data against a Set and the model, and fail if
+// they disagree or the tree breaks an invariant.
+func FuzzOperations(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{fuzzInsert, 1, fuzzInsert, 2, fuzzInsert, 3, fuzzFindGE, 2, fuzzDeleteWithIterator, 0, fuzzPrev, 0})
+	f.Add([]byte{fuzzInsert, 5, fuzzFindLE, 4, fuzzNext, 0, fuzzNext, 0, fuzzPrev, 0, fuzzDeleteWithKey, 5})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s := NewSet(WithIteratorChecks[int]())
+		m := &fuzzModel{cursor: cursorLimit}
+		it := s.Limit()
+		for len(data) >= 2 {
+			op, key := int(data[0])%numFuzzOps, int(data[1])
+			data = data[2:]
+			switch op {
+			case fuzzInsert:
+				i, found := slices.BinarySearch(m.keys, key)
+				if !found {
+					m.keys = slices.Insert(m.keys, i, key)
+				}
+				if s.Insert(key) == found {
+					t.Fatalf("Insert(%d) disagrees with the model", key)
+				}
+			case fuzzDeleteWithKey:
+				i, found := slices.BinarySearch(m.keys, key)
+				if found {
+					m.keys = slices.Delete(m.keys, i, i+1)
+				}
+				if s.DeleteWithKey(key) != found {
+					t.Fatalf("DeleteWithKey(%d) disagrees with the model", key)
+				}
+				if found && m.cursor == cursorItem && m.key == key {
+					// The iterator is now invalid; start over.
+					it, m.cursor = s.Limit(), cursorLimit
+				}
+			case fuzzDeleteWithIterator:
+				if m.cursor != cursorItem {
+					continue
+				}
+				i := m.index()
+				m.keys = slices.Delete(m.keys, i, i+1)
+				m.seek(i)
+				it = s.DeleteWithIterator(it)
+			case fuzzFindGE:
+				i, _ := slices.BinarySearch(m.keys, key)
+				m.seek(i)
+				it = s.FindGE(key)
+			case fuzzFindLE:
+				i, found := slices.BinarySearch(m.keys, key)
+				if !found {
+					i--
+				}
+				m.seek(i)
+				it = s.FindLE(key)
+			case fuzzNext:
+				switch m.cursor {
+				case cursorLimit:
+					continue
+				case cursorNegativeLimit:
+					m.seek(0)
+				default:
+					m.seek(m.index() + 1)
+				}
+				it = it.Next()
+			case fuzzPrev:
+				switch m.cursor {
+				case cursorNegativeLimit:
+					continue
+				case cursorLimit:
+					m.seek(len(m.keys) - 1)
+				default:
+					m.seek(m.index() - 1)
+				}
+				it = it.Prev()
+			}
+
+			if err := s.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Collect(s.All()); !slices.Equal(got, m.keys) {
+				t.Fatalf("tree holds %v, model holds %v", got, m.keys)
+			}
+			switch m.cursor {
+			case cursorNegativeLimit:
+				testAssert(t, it.NegativeLimit(), "iterator should be at NegativeLimit")
+			case cursorLimit:
+				testAssert(t, it.Limit(), "iterator should be at Limit")
+			default:
+				if it.Limit() || it.NegativeLimit() || it.Item() != m.key {
+					t.Fatalf("iterator should point to %d", m.key)
+				}
+			}
+		}
+	})
+}
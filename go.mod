@@ -0,0 +1,3 @@
+module github.com/yasushi-saito/rbtree
+
+go 1.23
@@ -0,0 +1,423 @@
+package rbtree
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Interval is the half-open range [Lo, Hi).
+type Interval[K any] struct {
+	Lo, Hi K
+}
+
+// IntervalTree maps intervals to values and finds the intervals that
+// overlap a given range or point. It is a red-black tree ordered by
+// (Lo, Hi) in which every subtree is augmented with the maximum Hi
+// under it.
+//
+// The same nodes also form a priority search tree: each node holds the
+// interval with the largest Hi among those in its subtree that no
+// ancestor holds. Overlapping and Stabbing walk it to report k
+// intervals in O(log n + k) time. Keeping it up to date makes Insert and
+// Delete take O(log² n) time.
+//
+// An interval can be stored at most once. Intervals with Lo == Hi are
+// allowed but empty, so they never overlap anything, and neither do
+// empty query ranges.
+type IntervalTree[K, V any] struct {
+	tree    *Set[intervalEntry[K, V]]
+	compare func(a, b K) int
+
+	// Nodes whose subtrees the last operation changed. Kept between
+	// operations only to reuse the memory.
+	touched  []*node[intervalEntry[K, V]]
+	repaired []*node[intervalEntry[K, V]]
+	floating []*node[intervalEntry[K, V]]
+}
+
+type intervalEntry[K, V any] struct {
+	iv    Interval[K]
+	value V
+}
+
+// The part of a node of an IntervalTree that the tree keeps in
+// node.aux.
+type intervalAux[K, V any] struct {
+	// The largest Hi of a non-empty interval in the subtree.
+	max maxEnd[K]
+
+	// The node whose interval this node holds in the priority search
+	// tree, or nil if its subtree has none left to hold.
+	heap *node[intervalEntry[K, V]]
+	// The node's own interval is held by the node or an ancestor. If
+	// not, queries check it when they visit the node.
+	held bool
+	// The interval has been taken out of the priority search tree and
+	// is waiting to be put back.
+	floating bool
+	// The node is in the set being repaired.
+	mark bool
+}
+
+type intervalNode[K, V any] struct {
+	node node[intervalEntry[K, V]]
+	aux  intervalAux[K, V]
+}
+
+// Create a new empty interval tree. compare returns 0 if a==b, <0 if
+// a<b, >0 if a>b.
+func NewIntervalTreeFunc[K, V any](compare func(a, b K) int) *IntervalTree[K, V] {
+	t := &IntervalTree[K, V]{compare: compare}
+	t.tree = NewSetFunc(t.compareEntries)
+	t.tree.augmenter = t
+	return t
+}
+
+// Create a new empty interval tree ordered by the natural order of K.
+func NewIntervalTree[K cmp.Ordered, V any]() *IntervalTree[K, V] {
+	return NewIntervalTreeFunc[K, V](cmp.Compare[K])
+}
+
+func (t *IntervalTree[K, V]) compareEntries(a, b intervalEntry[K, V]) int {
+	if c := t.compare(a.iv.Lo, b.iv.Lo); c != 0 {
+		return c
+	}
+	return t.compare(a.iv.Hi, b.iv.Hi)
+}
+
+// The largest Hi of a non-empty interval in a subtree. ok is false if
+// there is none.
+type maxEnd[K any] struct {
+	hi K
+	ok bool
+}
+
+func (t *IntervalTree[K, V]) larger(a, b maxEnd[K]) maxEnd[K] {
+	if !a.ok || (b.ok && t.compare(b.hi, a.hi) > 0) {
+		return b
+	}
+	return a
+}
+
+func getIntervalAux[K, V any](n *node[intervalEntry[K, V]]) *intervalAux[K, V] {
+	return n.aux.(*intervalAux[K, V])
+}
+
+func (t *IntervalTree[K, V]) newNode(e intervalEntry[K, V]) *node[intervalEntry[K, V]] {
+	in := &intervalNode[K, V]{}
+	in.node.item = e
+	in.node.aux = &in.aux
+	return &in.node
+}
+
+// Recompute the max endpoint of n, and remember n for repair. Empty
+// intervals are left out so that AnyOverlap can rely on the maximum.
+func (t *IntervalTree[K, V]) update(n *node[intervalEntry[K, V]]) {
+	var max maxEnd[K]
+	if t.isEmpty(n.item.iv) {
+		max = maxEnd[K]{}
+	} else {
+		max = maxEnd[K]{n.item.iv.Hi, true}
+	}
+	if n.left != nil {
+		max = t.larger(max, getIntervalAux(n.left).max)
+	}
+	if n.right != nil {
+		max = t.larger(max, getIntervalAux(n.right).max)
+	}
+	getIntervalAux(n).max = max
+	t.touched = append(t.touched, n)
+}
+
+// An IntervalTree has no node pool, so its nodes are never recycled.
+func (t *IntervalTree[K, V]) clear(n *node[intervalEntry[K, V]]) {
+	*getIntervalAux(n) = intervalAux[K, V]{}
+}
+
+func (t *IntervalTree[K, V]) sameAs(other augmenterHook[intervalEntry[K, V]]) bool {
+	_, ok := other.(*IntervalTree[K, V])
+	return ok
+}
+
+func (t *IntervalTree[K, V]) isEmpty(iv Interval[K]) bool {
+	return t.compare(iv.Lo, iv.Hi) >= 0
+}
+
+// Check if some non-empty interval in n's subtree ends after lo.
+func (t *IntervalTree[K, V]) endsAfter(n *node[intervalEntry[K, V]], lo K) bool {
+	if n == nil {
+		return false
+	}
+	max := getIntervalAux(n).max
+	return max.ok && t.compare(max.hi, lo) > 0
+}
+
+// Return the number of intervals in the tree.
+func (t *IntervalTree[K, V]) Len() int {
+	return t.tree.Len()
+}
+
+// Insert an interval and its value. If the interval is already in the
+// tree, do nothing and return false. Else return true.
+//
+// REQUIRES: iv.Lo <= iv.Hi
+func (t *IntervalTree[K, V]) Insert(iv Interval[K], value V) bool {
+	if t.compare(iv.Lo, iv.Hi) > 0 {
+		panic("IntervalTree.Insert called with Lo > Hi.")
+	}
+	inserted := t.tree.Insert(intervalEntry[K, V]{iv, value})
+	t.repair(nil)
+	return inserted
+}
+
+// Find the value stored for exactly iv. The 2nd return value is true
+// iff iv is in the tree.
+func (t *IntervalTree[K, V]) Get(iv Interval[K]) (V, bool) {
+	e, ok := t.tree.Lookup(intervalEntry[K, V]{iv: iv})
+	return e.value, ok
+}
+
+// Delete the interval iv. Return true iff it was found.
+func (t *IntervalTree[K, V]) Delete(iv Interval[K]) bool {
+	n, exact := t.tree.findGE(intervalEntry[K, V]{iv: iv})
+	if !exact {
+		return false
+	}
+	t.tree.doDelete(n)
+	t.repair(n)
+	return true
+}
+
+// Return a sequence of all intervals, ordered by (Lo, Hi).
+func (t *IntervalTree[K, V]) All() iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		for e := range t.tree.All() {
+			if !yield(e.iv, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Return a sequence of the intervals that overlap [lo, hi), in no
+// particular order. This takes O(log n + k) time to produce k
+// intervals. If lo >= hi, the range is empty and the sequence is too.
+func (t *IntervalTree[K, V]) Overlapping(lo, hi K) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		if t.compare(lo, hi) >= 0 {
+			return
+		}
+		t.overlapping(t.tree.root, lo, hi, false, yield)
+	}
+}
+
+// Return a sequence of the intervals that contain point, in no
+// particular order. This takes O(log n + k) time to produce k
+// intervals.
+func (t *IntervalTree[K, V]) Stabbing(point K) iter.Seq2[Interval[K], V] {
+	return func(yield func(Interval[K], V) bool) {
+		t.overlapping(t.tree.root, point, point, true, yield)
+	}
+}
+
+// Find some interval that overlaps [lo, hi) in O(log n) time. The 3rd
+// return value is false if there is none, which is always the case if
+// lo >= hi.
+func (t *IntervalTree[K, V]) AnyOverlap(lo, hi K) (Interval[K], V, bool) {
+	n := t.tree.root
+	if t.compare(lo, hi) >= 0 {
+		n = nil
+	}
+	for n != nil {
+		if t.overlaps(n.item.iv, lo, hi, false) {
+			return n.item.iv, n.item.value, true
+		}
+		// If the left subtree has an interval ending after lo, then
+		// either one of them overlaps or every interval to the right
+		// starts too late as well.
+		if t.endsAfter(n.left, lo) {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	var iv Interval[K]
+	var value V
+	return iv, value, false
+}
+
+// Check if iv overlaps [lo, hi), or [lo, hi] if closed is true.
+func (t *IntervalTree[K, V]) overlaps(iv Interval[K], lo, hi K, closed bool) bool {
+	return t.startsBefore(iv, hi, closed) && t.compare(iv.Hi, lo) > 0 && !t.isEmpty(iv)
+}
+
+func (t *IntervalTree[K, V]) startsBefore(iv Interval[K], hi K, closed bool) bool {
+	c := t.compare(iv.Lo, hi)
+	return c < 0 || (closed && c == 0)
+}
+
+// Yield the intervals in n's subtree that overlap the query. Return
+// false if yield asked to stop.
+//
+// Every interval in the subtree that no ancestor of n holds ends no
+// later than the one n holds, so the walk stops wherever that one ends
+// by lo. Off the search path for hi, every subtree lies before hi, so a
+// node that is not pruned holds an overlapping interval. The walk thus
+// visits O(log n) nodes on the path and O(1) nodes per interval.
+func (t *IntervalTree[K, V]) overlapping(n *node[intervalEntry[K, V]], lo, hi K, closed bool, yield func(Interval[K], V) bool) bool {
+	for n != nil {
+		a := getIntervalAux(n)
+		if a.heap == nil || t.isEmpty(a.heap.item.iv) || t.compare(a.heap.item.iv.Hi, lo) <= 0 {
+			return true
+		}
+		if e := a.heap.item; t.overlaps(e.iv, lo, hi, closed) && !yield(e.iv, e.value) {
+			return false
+		}
+		if e := n.item; !a.held && t.overlaps(e.iv, lo, hi, closed) && !yield(e.iv, e.value) {
+			return false
+		}
+		if !t.overlapping(n.left, lo, hi, closed, yield) {
+			return false
+		}
+		// Everything to the right starts too late.
+		if !t.startsBefore(n.item.iv, hi, closed) {
+			return true
+		}
+		n = n.right
+	}
+	return true
+}
+
+//
+// Priority search tree maintenance
+//
+
+// Check if the interval of node a ranks above that of b in the priority
+// search tree: non-empty intervals by Hi, then empty ones. A nil b ranks
+// below everything.
+func (t *IntervalTree[K, V]) above(a, b *node[intervalEntry[K, V]]) bool {
+	if b == nil {
+		return true
+	}
+	if t.isEmpty(b.item.iv) {
+		return !t.isEmpty(a.item.iv)
+	}
+	return !t.isEmpty(a.item.iv) && t.compare(a.item.iv.Hi, b.item.iv.Hi) > 0
+}
+
+// Fill the empty slot of n with the highest ranked interval among n's
+// own, if no one holds it, and those its children hold, then refill the
+// child that gave up its interval, and so on down.
+func (t *IntervalTree[K, V]) refill(n *node[intervalEntry[K, V]]) {
+	for {
+		a := getIntervalAux(n)
+		var best, from *node[intervalEntry[K, V]]
+		if !a.held && !a.floating {
+			best = n
+		}
+		for _, c := range [2]*node[intervalEntry[K, V]]{n.left, n.right} {
+			if c == nil {
+				continue
+			}
+			if h := getIntervalAux(c).heap; h != nil && t.above(h, best) {
+				best, from = h, c
+			}
+		}
+		a.heap = best
+		if from == nil {
+			if best != nil {
+				a.held = true
+			}
+			return
+		}
+		getIntervalAux(from).heap = nil
+		n = from
+	}
+}
+
+// Add the interval of node p, which no one holds, to the priority
+// search tree under n.
+//
+// REQUIRES: p is in n's subtree.
+func (t *IntervalTree[K, V]) push(n, p *node[intervalEntry[K, V]]) {
+	for {
+		a := getIntervalAux(n)
+		if t.above(p, a.heap) {
+			getIntervalAux(p).held = true
+			p, a.heap = a.heap, p
+			if p == nil {
+				return
+			}
+			getIntervalAux(p).held = false
+		}
+		if p == n {
+			// Only n or its ancestors may hold it. It stays unheld.
+			return
+		}
+		if t.compareEntries(p.item, n.item) < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
+
+// Restore the priority search tree after the red-black tree changed
+// the subtrees of the nodes passed to update. removed is the node that
+// was deleted, if any.
+//
+// The changed nodes and their ancestors give up the intervals they hold,
+// which leaves every subtree hanging off them a valid priority search
+// tree of its own. The nodes are then refilled bottom up, and the
+// intervals they gave up pushed back from the root. Both take O(log n)
+// time for each of the O(log n) nodes.
+func (t *IntervalTree[K, V]) repair(removed *node[intervalEntry[K, V]]) {
+	for _, n := range t.touched {
+		for ; n != nil && n != removed && !getIntervalAux(n).mark; n = n.parent {
+			getIntervalAux(n).mark = true
+			t.repaired = append(t.repaired, n)
+		}
+	}
+	giveUp := func(n *node[intervalEntry[K, V]]) {
+		a := getIntervalAux(n)
+		if a.heap != nil && a.heap != removed {
+			getIntervalAux(a.heap).floating = true
+			t.floating = append(t.floating, a.heap)
+		}
+		a.heap = nil
+	}
+	if removed != nil {
+		giveUp(removed)
+	}
+	for _, n := range t.repaired {
+		giveUp(n)
+		getIntervalAux(n).held = false
+	}
+	for _, p := range t.floating {
+		getIntervalAux(p).held = false
+	}
+	t.refillMarked(t.tree.root)
+	for _, p := range t.floating {
+		getIntervalAux(p).floating = false
+		t.push(t.tree.root, p)
+	}
+	for _, n := range t.repaired {
+		getIntervalAux(n).mark = false
+	}
+	clear(t.touched)
+	clear(t.repaired)
+	clear(t.floating)
+	t.touched = t.touched[:0]
+	t.repaired = t.repaired[:0]
+	t.floating = t.floating[:0]
+}
+
+// Refill the marked nodes under n, children first.
+func (t *IntervalTree[K, V]) refillMarked(n *node[intervalEntry[K, V]]) {
+	if n == nil || !getIntervalAux(n).mark {
+		return
+	}
+	t.refillMarked(n.left)
+	t.refillMarked(n.right)
+	t.refill(n)
+}
@@ -0,0 +1,214 @@
+package rbtree
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Print the intervals in seq ordered by (Lo, Hi).
+func intervalsToString[V any](seq iter.Seq2[Interval[int], V]) string {
+	var ivs []Interval[int]
+	for iv := range seq {
+		ivs = append(ivs, iv)
+	}
+	slices.SortFunc(ivs, func(a, b Interval[int]) int {
+		return cmp.Or(a.Lo-b.Lo, a.Hi-b.Hi)
+	})
+	s := ""
+	for _, iv := range ivs {
+		s += fmt.Sprintf("[%d,%d)", iv.Lo, iv.Hi)
+	}
+	return s
+}
+
+// Check the priority search tree: every interval is held at most once,
+// by its own node or an ancestor, and the held flags say which are; and
+// each node holds the highest ranked interval in its subtree that no
+// ancestor holds.
+func checkIntervalHeap(t *testing.T, tree *IntervalTree[int, int]) {
+	t.Helper()
+	type inode = *node[intervalEntry[int, int]]
+	holder := map[inode]inode{}
+	for it := tree.tree.Min(); !it.Limit(); it = it.Next() {
+		a := getIntervalAux(it.node)
+		if a.mark || a.floating {
+			t.Fatalf("%v: repair state left behind", it.node.item.iv)
+		}
+		if h := a.heap; h != nil {
+			if holder[h] != nil {
+				t.Fatalf("%v is held twice", h.item.iv)
+			}
+			holder[h] = it.node
+		}
+	}
+	for it := tree.tree.Min(); !it.Limit(); it = it.Next() {
+		if getIntervalAux(it.node).held != (holder[it.node] != nil) {
+			t.Fatalf("%v: wrong held flag", it.node.item.iv)
+		}
+	}
+	// Return the intervals in n's subtree that no node under n holds,
+	// checking n on the way.
+	var walk func(n inode, ancestors []inode) []inode
+	walk = func(n inode, ancestors []inode) []inode {
+		if n == nil {
+			return nil
+		}
+		ancestors = append(ancestors, n)
+		free := append(walk(n.left, ancestors), walk(n.right, ancestors)...)
+		free = append(free, n)
+		// The intervals available to n.
+		var avail []inode
+		for _, f := range free {
+			if h := holder[f]; h == nil || h == n || !slices.Contains(ancestors, h) {
+				avail = append(avail, f)
+			}
+		}
+		h := getIntervalAux(n).heap
+		if h == nil {
+			if len(avail) > 0 {
+				t.Fatalf("%v holds nothing, but %v is available", n.item.iv, avail[0].item.iv)
+			}
+			return free
+		}
+		if !slices.Contains(avail, h) {
+			t.Fatalf("%v holds %v, which is not available to it", n.item.iv, h.item.iv)
+		}
+		for _, f := range avail {
+			if tree.above(f, h) {
+				t.Fatalf("%v holds %v, but %v ranks above it", n.item.iv, h.item.iv, f.item.iv)
+			}
+		}
+		return slices.DeleteFunc(free, func(f inode) bool { return f == h })
+	}
+	walk(tree.tree.root, nil)
+}
+
+func TestIntervalTree(t *testing.T) {
+	tree := NewIntervalTree[int, string]()
+	_, _, ok := tree.AnyOverlap(0, 100)
+	testAssert(t, !ok, "empty AnyOverlap")
+	testAssert(t, tree.Insert(Interval[int]{1, 5}, "a"), "insert a")
+	testAssert(t, tree.Insert(Interval[int]{3, 4}, "b"), "insert b")
+	testAssert(t, tree.Insert(Interval[int]{6, 9}, "c"), "insert c")
+	testAssert(t, tree.Insert(Interval[int]{7, 7}, "empty"), "insert empty")
+	testAssert(t, !tree.Insert(Interval[int]{1, 5}, "dup"), "insert dup")
+	testAssert(t, tree.Len() == 4, "len")
+
+	testAssert(t, intervalsToString(tree.Overlapping(4, 7)) == "[1,5)[6,9)", "overlap 4-7")
+	testAssert(t, intervalsToString(tree.Overlapping(5, 6)) == "", "overlap 5-6")
+	testAssert(t, intervalsToString(tree.Overlapping(0, 100)) == "[1,5)[3,4)[6,9)", "overlap all")
+	testAssert(t, intervalsToString(tree.Stabbing(3)) == "[1,5)[3,4)", "stab 3")
+	testAssert(t, intervalsToString(tree.Stabbing(5)) == "", "stab 5")
+	testAssert(t, intervalsToString(tree.Stabbing(7)) == "[6,9)", "stab 7")
+
+	// Empty query ranges overlap nothing, even inside an interval.
+	testAssert(t, intervalsToString(tree.Overlapping(3, 3)) == "", "overlap 3-3")
+	testAssert(t, intervalsToString(tree.Overlapping(4, 2)) == "", "overlap 4-2")
+	_, _, ok = tree.AnyOverlap(3, 3)
+	testAssert(t, !ok, "AnyOverlap 3-3")
+
+	iv, v, ok := tree.AnyOverlap(8, 10)
+	testAssert(t, ok && iv == Interval[int]{6, 9} && v == "c", "AnyOverlap 8-10")
+	_, _, ok = tree.AnyOverlap(9, 10)
+	testAssert(t, !ok, "AnyOverlap 9-10")
+
+	v, ok = tree.Get(Interval[int]{3, 4})
+	testAssert(t, ok && v == "b", "Get")
+	testAssert(t, tree.Delete(Interval[int]{1, 5}), "delete")
+	testAssert(t, !tree.Delete(Interval[int]{1, 5}), "delete again")
+	testAssert(t, intervalsToString(tree.Stabbing(3)) == "[3,4)", "stab 3 after delete")
+}
+
+func TestIntervalTreeInsertAllocs(t *testing.T) {
+	tree := NewIntervalTree[int, int]()
+	for i := 0; i < 1000; i++ {
+		tree.Insert(Interval[int]{i, i + 10}, i)
+	}
+	// Maintaining the max endpoints must not allocate; only the new
+	// node does.
+	lo := 1000
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.Insert(Interval[int]{lo, lo + 10}, lo)
+		lo++
+	})
+	testAssert(t, allocs <= 1, fmt.Sprint("Insert allocations: ", allocs))
+}
+
+// Overlapping must take O(log n + k) time, even when the overlapping
+// intervals are spread thinly among others that end too early.
+func TestOverlappingOutputSensitive(t *testing.T) {
+	const n = 1 << 14
+	calls := 0
+	tree := NewIntervalTreeFunc[int, int](func(a, b int) int {
+		calls++
+		return cmp.Compare(a, b)
+	})
+	for i := 0; i < n; i++ {
+		hi := 3*i + 1
+		if i%16 == 0 {
+			hi = 3 * n
+		}
+		tree.Insert(Interval[int]{3 * i, hi}, i)
+	}
+	calls = 0
+	k := 0
+	for range tree.Overlapping(3*n-1, 3*n) {
+		k++
+	}
+	testAssert(t, k == n/16, "overlap count")
+	testAssert(t, calls <= 12*(k+14), fmt.Sprintf("%d comparisons for %d intervals", calls, k))
+}
+
+func TestIntervalTreeRandomized(t *testing.T) {
+	const maxPoint = 200
+	tree := NewIntervalTree[int, int]()
+	intervals := map[Interval[int]]bool{}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 3000; i++ {
+		lo := r.Intn(maxPoint)
+		iv := Interval[int]{lo, lo + r.Intn(20)}
+		if r.Intn(3) == 0 {
+			testAssert(t, tree.Delete(iv) == intervals[iv], "delete")
+			delete(intervals, iv)
+		} else {
+			testAssert(t, tree.Insert(iv, i) == !intervals[iv], "insert")
+			intervals[iv] = true
+		}
+		checkIntervalHeap(t, tree)
+
+		qlo := r.Intn(maxPoint)
+		qhi := qlo + r.Intn(10)
+		want := 0
+		for iv := range intervals {
+			if iv.Lo < qhi && iv.Hi > qlo && iv.Lo < iv.Hi && qlo < qhi {
+				want++
+			}
+		}
+		got := 0
+		for iv := range tree.Overlapping(qlo, qhi) {
+			testAssert(t, iv.Lo < qhi && iv.Hi > qlo, "Overlapping returned a non-overlapping interval")
+			got++
+		}
+		if got != want {
+			t.Fatalf("Overlapping(%d, %d): got %d intervals, want %d", qlo, qhi, got, want)
+		}
+		_, _, ok := tree.AnyOverlap(qlo, qhi)
+		testAssert(t, ok == (want > 0), "AnyOverlap")
+
+		want = 0
+		for iv := range intervals {
+			if iv.Lo <= qlo && qlo < iv.Hi {
+				want++
+			}
+		}
+		got = 0
+		for range tree.Stabbing(qlo) {
+			got++
+		}
+		testAssert(t, got == want, "Stabbing")
+	}
+}
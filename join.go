@@ -0,0 +1,405 @@
+package rbtree
+
+// Join-based algorithms, after Blelloch, Ferizovic and Sun, "Just Join
+// for Parallel Ordered Sets" (SPAA 2016).
+//
+// All of these move nodes between trees instead of copying items, so
+// the trees passed in are left empty and any iterators into them become
+// invalid.
+//
+// The internal helpers work on detached subtrees, identified by root
+// nodes whose parent is nil. They borrow the receiver's root field as
+// scratch space, since rotations update it when they rotate at the top
+// of a subtree. Each subtree carries its black height, so that join
+// need not walk a spine to measure it; the public functions measure
+// their inputs once.
+
+// Split the tree into the items < key and the items >= key, in O(log n)
+// time. The receiver is left empty.
+func (root *Set[T]) Split(key T) (left, right *Set[T]) {
+	l, r := root.split(measure(root.root), key)
+	left, right = root.newEmpty(), root.newEmpty()
+	left.setRoot(l.n)
+	right.setRoot(r.n)
+	root.setRoot(nil)
+	root.invalidateIterators()
+	return left, right
+}
+
+// Return a tree holding the items of left, then pivot, then the items of
+// right, in O(log n) time. left and right are left empty.
+//
+// REQUIRES: every item in left < pivot < every item in right. Both
+// trees were created with the same options and the same compare
+// function; the result takes left's codec and node pool.
+func Join[T any](left *Set[T], pivot T, right *Set[T]) *Set[T] {
+	left.checkSameConfig(right, "Join")
+	if (left.maxNode != nil && !left.inOrder(left.maxNode.item, pivot)) ||
+		(right.minNode != nil && !left.inOrder(pivot, right.minNode.item)) {
+		panic("Join called with trees that overlap the pivot.")
+	}
+	result := left.newEmpty()
+	result.setRoot(result.join(measure(left.root), result.newNode(pivot), measure(right.root)).n)
+	left.setRoot(nil)
+	right.setRoot(nil)
+	left.invalidateIterators()
+	right.invalidateIterators()
+	return result
+}
+
+// Return a tree holding the items that are in a or b. If an item is in
+// both, the result holds merge(a's item, b's item); if merge is nil, it
+// holds a's item. a and b are left empty. This takes O(m log(n/m + 1))
+// time, where m and n are the sizes of the smaller and larger input.
+//
+// REQUIRES: neither tree allows duplicates, and merge returns an item
+// equal to its arguments. Both trees were created with the same options
+// and the same compare function; the result takes a's codec and node
+// pool.
+func Union[T any](a, b *Set[T], merge func(x, y T) T) *Set[T] {
+	return a.setOperation(b, func() *node[T] { return a.union(measure(a.root), measure(b.root), merge).n })
+}
+
+// Return a tree holding the items that are in both a and b, as
+// merge(a's item, b's item), or a's item if merge is nil. a and b are
+// left empty. See Union for the running time.
+//
+// REQUIRES: neither tree allows duplicates. Both trees were created
+// with the same options and the same compare function.
+func Intersection[T any](a, b *Set[T], merge func(x, y T) T) *Set[T] {
+	return a.setOperation(b, func() *node[T] { return a.intersection(measure(a.root), measure(b.root), merge).n })
+}
+
+// Return a tree holding the items of a that are not in b. a and b are
+// left empty. See Union for the running time.
+//
+// REQUIRES: neither tree allows duplicates. Both trees were created
+// with the same options and the same compare function.
+func Difference[T any](a, b *Set[T]) *Set[T] {
+	return a.setOperation(b, func() *node[T] { return a.difference(measure(a.root), measure(b.root)).n })
+}
+
+// Return a tree holding the items that are in exactly one of a and b.
+// a and b are left empty. See Union for the running time.
+//
+// REQUIRES: neither tree allows duplicates. Both trees were created
+// with the same options and the same compare function.
+func SymmetricDifference[T any](a, b *Set[T]) *Set[T] {
+	return a.setOperation(b, func() *node[T] { return a.symmetricDifference(measure(a.root), measure(b.root)).n })
+}
+
+func (root *Set[T]) setOperation(other *Set[T], op func() *node[T]) *Set[T] {
+	root.checkSameConfig(other, "set operation")
+	if root.multi {
+		panic("set operation called on a tree that allows duplicates.")
+	}
+	result := root.newEmpty()
+	n := op()
+	root.setRoot(nil)
+	other.setRoot(nil)
+	root.invalidateIterators()
+	other.invalidateIterators()
+	result.setRoot(n)
+	return result
+}
+
+// Panic unless root and other may exchange nodes: a node allocated by
+// one tree's augmenter must be usable by the other's, and both must
+// agree on duplicates and iterator checks. Compare functions cannot be
+// compared, so they are left to the caller.
+func (root *Set[T]) checkSameConfig(other *Set[T], op string) {
+	sameAug := root.augmenter == nil && other.augmenter == nil
+	if root.augmenter != nil && other.augmenter != nil {
+		sameAug = root.augmenter.sameAs(other.augmenter)
+	}
+	if !sameAug || root.multi != other.multi || root.checkIterators != other.checkIterators {
+		panic("rbtree: " + op + " called with trees of different configurations")
+	}
+}
+
+// Check if a may precede b in the tree.
+func (root *Set[T]) inOrder(a, b T) bool {
+	comp := root.compare(a, b)
+	return comp < 0 || (comp == 0 && root.multi)
+}
+
+// Create an empty tree with the same configuration as root.
+func (root *Set[T]) newEmpty() *Set[T] {
+	return &Set[T]{
+		compare:   root.compare,
+		augmenter: root.augmenter,
+		multi:     root.multi,
+		codec:     root.codec,
+		pool:      root.pool,
+
+		checkIterators: root.checkIterators,
+	}
+}
+
+// Make the detached subtree n the contents of the tree.
+func (root *Set[T]) setRoot(n *node[T]) {
+	if n != nil {
+		n.parent = nil
+		n.color = black
+	}
+	root.root = n
+	root.count = getSize(n)
+	root.recomputeMinNode()
+	root.recomputeMaxNode()
+}
+
+// A detached subtree and its black height: the number of black nodes on
+// any path from its root down to a nil child.
+type subtree[T any] struct {
+	n  *node[T]
+	bh int
+}
+
+// Return the detached subtree n with its black height, which takes
+// O(log n) time.
+func measure[T any](n *node[T]) subtree[T] {
+	return subtree[T]{n, blackHeight(n)}
+}
+
+// Detach and return the children of n.
+func detachChildren[T any](n *node[T]) (left, right *node[T]) {
+	left, right = n.left, n.right
+	if left != nil {
+		left.parent = nil
+	}
+	if right != nil {
+		right.parent = nil
+	}
+	n.left, n.right = nil, nil
+	return left, right
+}
+
+// Detach and return the children of t's root, with their black heights.
+//
+// REQUIRES: t.n != nil
+func detachSubtrees[T any](t subtree[T]) (left, right subtree[T]) {
+	l, r := detachChildren(t.n)
+	h := t.bh
+	if t.n.color == black {
+		h--
+	}
+	return subtree[T]{l, h}, subtree[T]{r, h}
+}
+
+// Return the number of black nodes on any path from n down to a nil
+// child.
+func blackHeight[T any](n *node[T]) int {
+	h := 0
+	for ; n != nil; n = n.left {
+		if n.color == black {
+			h++
+		}
+	}
+	return h
+}
+
+// Color the root of t black, and return t with its new black height.
+func blacken[T any](t subtree[T]) subtree[T] {
+	if t.n != nil && t.n.color != black {
+		t.n.color = black
+		t.bh++
+	}
+	return t
+}
+
+// Join the detached subtrees l and r with the node k between them, and
+// return the result. This takes O(|l.bh - r.bh| + 1) time.
+func (root *Set[T]) join(l subtree[T], k *node[T], r subtree[T]) subtree[T] {
+	k.parent, k.left, k.right = nil, nil, nil
+	l, r = blacken(l), blacken(r)
+	if l.bh == r.bh {
+		k.left, k.right = l.n, r.n
+		if l.n != nil {
+			l.n.parent = k
+		}
+		if r.n != nil {
+			r.n.parent = k
+		}
+		k.color = black
+		root.update(k)
+		return subtree[T]{k, l.bh + 1}
+	}
+
+	// Walk down the spine of the taller tree that faces the shorter
+	// one, to the first black node c with the same black height as the
+	// shorter tree. Put k in c's place, with c and the shorter tree as
+	// its children. This is like inserting a leaf, so k is made red
+	// and the same fixup restores the red-black properties.
+	tall, short := l, r
+	if r.bh > l.bh {
+		tall, short = r, l
+	}
+	root.root = tall.n
+	var p *node[T]
+	c, h := tall.n, tall.bh
+	for !(getColor(c) == black && h == short.bh) {
+		if c.color == black {
+			h--
+		}
+		p = c
+		if tall == l {
+			c = c.right
+		} else {
+			c = c.left
+		}
+	}
+	if tall == l {
+		k.left, k.right = c, short.n
+		p.right = k
+	} else {
+		k.left, k.right = short.n, c
+		p.left = k
+	}
+	k.parent = p
+	if c != nil {
+		c.parent = k
+	}
+	if short.n != nil {
+		short.n.parent = k
+	}
+	root.updatePath(k)
+	if root.insertFixup(k) {
+		tall.bh++
+	}
+	return subtree[T]{root.root, tall.bh}
+}
+
+// Concatenate the detached subtrees l and r.
+func (root *Set[T]) concat(l, r subtree[T]) subtree[T] {
+	if l.n == nil {
+		return r
+	}
+	if r.n == nil {
+		return l
+	}
+	rest, last := root.splitLast(l)
+	return root.join(rest, last, r)
+}
+
+// Remove the maximum node from the detached subtree t. Return the rest
+// of the subtree and the (detached) maximum node.
+//
+// REQUIRES: t.n != nil
+func (root *Set[T]) splitLast(t subtree[T]) (rest subtree[T], last *node[T]) {
+	left, right := detachSubtrees(t)
+	if right.n == nil {
+		return left, t.n
+	}
+	rest, last = root.splitLast(right)
+	return root.join(left, t.n, rest), last
+}
+
+// Split the detached subtree t into the nodes < key and the nodes >=
+// key.
+func (root *Set[T]) split(t subtree[T], key T) (l, r subtree[T]) {
+	if t.n == nil {
+		return t, t
+	}
+	left, right := detachSubtrees(t)
+	if root.compare(key, t.n.item) <= 0 {
+		l, r = root.split(left, key)
+		return l, root.join(r, t.n, right)
+	}
+	l, r = root.split(right, key)
+	return root.join(left, t.n, l), r
+}
+
+// Split the detached subtree t into the nodes < key, the node equal to
+// key (or nil), and the nodes > key.
+func (root *Set[T]) split3(t subtree[T], key T) (l subtree[T], eq *node[T], r subtree[T]) {
+	if t.n == nil {
+		return t, nil, t
+	}
+	left, right := detachSubtrees(t)
+	comp := root.compare(key, t.n.item)
+	if comp == 0 {
+		return left, t.n, right
+	}
+	if comp < 0 {
+		l, eq, r = root.split3(left, key)
+		return l, eq, root.join(r, t.n, right)
+	}
+	l, eq, r = root.split3(right, key)
+	return root.join(left, t.n, l), eq, r
+}
+
+func (root *Set[T]) union(a, b subtree[T], merge func(x, y T) T) subtree[T] {
+	if a.n == nil {
+		return b
+	}
+	if b.n == nil {
+		return a
+	}
+	aLeft, aRight := detachSubtrees(a)
+	bLeft, eq, bRight := root.split3(b, a.n.item)
+	if eq != nil {
+		if merge != nil {
+			a.n.item = merge(a.n.item, eq.item)
+		}
+		root.freeNode(eq)
+	}
+	l := root.union(aLeft, bLeft, merge)
+	r := root.union(aRight, bRight, merge)
+	return root.join(l, a.n, r)
+}
+
+func (root *Set[T]) intersection(a, b subtree[T], merge func(x, y T) T) subtree[T] {
+	if a.n == nil || b.n == nil {
+		root.freeSubtree(a.n)
+		root.freeSubtree(b.n)
+		return subtree[T]{}
+	}
+	aLeft, aRight := detachSubtrees(a)
+	bLeft, eq, bRight := root.split3(b, a.n.item)
+	l := root.intersection(aLeft, bLeft, merge)
+	r := root.intersection(aRight, bRight, merge)
+	if eq == nil {
+		root.freeNode(a.n)
+		return root.concat(l, r)
+	}
+	if merge != nil {
+		a.n.item = merge(a.n.item, eq.item)
+	}
+	root.freeNode(eq)
+	return root.join(l, a.n, r)
+}
+
+func (root *Set[T]) difference(a, b subtree[T]) subtree[T] {
+	if a.n == nil || b.n == nil {
+		root.freeSubtree(b.n)
+		return a
+	}
+	bLeft, bRight := detachSubtrees(b)
+	aLeft, eq, aRight := root.split3(a, b.n.item)
+	root.freeNode(b.n)
+	if eq != nil {
+		root.freeNode(eq)
+	}
+	l := root.difference(aLeft, bLeft)
+	r := root.difference(aRight, bRight)
+	return root.concat(l, r)
+}
+
+func (root *Set[T]) symmetricDifference(a, b subtree[T]) subtree[T] {
+	if a.n == nil {
+		return b
+	}
+	if b.n == nil {
+		return a
+	}
+	aLeft, aRight := detachSubtrees(a)
+	bLeft, eq, bRight := root.split3(b, a.n.item)
+	l := root.symmetricDifference(aLeft, bLeft)
+	r := root.symmetricDifference(aRight, bRight)
+	if eq != nil {
+		root.freeNode(eq)
+		root.freeNode(a.n)
+		return root.concat(l, r)
+	}
+	return root.join(l, a.n, r)
+}
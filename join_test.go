@@ -0,0 +1,210 @@
+package rbtree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func newRandomSumSet(r *rand.Rand, n, maxKey int) (*Set[int], map[int]bool) {
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	keys := map[int]bool{}
+	for i := 0; i < n; i++ {
+		k := r.Intn(maxKey)
+		s.Insert(k)
+		keys[k] = true
+	}
+	return s, keys
+}
+
+// Check that s is a valid tree holding exactly keys.
+func checkSetContents(t *testing.T, s *Set[int], keys map[int]bool) {
+	t.Helper()
+	want := []int{}
+	for k := range keys {
+		want = append(want, k)
+	}
+	sort.Ints(want)
+	got := slices.Collect(s.All())
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	testAssert(t, s.Len() == len(want), "len")
+	testAssert(t, getColor(s.root) == black, "root color")
+	if len(want) > 0 {
+		testAssert(t, s.Min().Item() == want[0], "min")
+		testAssert(t, s.Max().Item() == want[len(want)-1], "max")
+		testAssert(t, s.root.parent == nil, "root parent")
+	} else {
+		testAssert(t, s.Min().Limit() && s.Max().NegativeLimit(), "empty min/max")
+	}
+	checkRedBlack(t, s.root)
+	checkSizes(t, s.root)
+	checkSums(t, s.root)
+	for i, k := range want {
+		testAssert(t, s.Rank(k) == i, "rank")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		s, keys := newRandomSumSet(r, r.Intn(100), 200)
+		key := r.Intn(220) - 10
+		left, right := s.Split(key)
+		lkeys, rkeys := map[int]bool{}, map[int]bool{}
+		for k := range keys {
+			if k < key {
+				lkeys[k] = true
+			} else {
+				rkeys[k] = true
+			}
+		}
+		checkSetContents(t, left, lkeys)
+		checkSetContents(t, right, rkeys)
+		checkSetContents(t, s, map[int]bool{})
+
+		// Join them back together around right's minimum.
+		if right.Len() > 0 {
+			pivot := right.Min().Item()
+			right.DeleteWithKey(pivot)
+			checkSetContents(t, Join(left, pivot, right), keys)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+		b := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+		keys := map[int]bool{1000: true}
+		for n := r.Intn(300); n > 0; n-- {
+			k := r.Intn(1000)
+			a.Insert(k)
+			keys[k] = true
+		}
+		for n := r.Intn(300); n > 0; n-- {
+			k := 1001 + r.Intn(1000)
+			b.Insert(k)
+			keys[k] = true
+		}
+		checkSetContents(t, Join(a, 1000, b), keys)
+		checkSetContents(t, a, map[int]bool{})
+		checkSetContents(t, b, map[int]bool{})
+	}
+}
+
+// The helpers carry black heights instead of measuring them, so check
+// that the heights they return are right.
+func TestSplitBlackHeights(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		s, _ := newRandomSumSet(r, r.Intn(300), 1000)
+		l, rest := s.split(measure(s.root), r.Intn(1000))
+		mid, rr := s.split(rest, r.Intn(1000))
+		for _, st := range []subtree[int]{l, mid, rr} {
+			testAssert(t, st.bh == blackHeight(st.n), "split height")
+		}
+		c := s.concat(l, rr)
+		testAssert(t, c.bh == blackHeight(c.n), "concat height")
+		u := s.union(c, mid, nil)
+		testAssert(t, u.bh == blackHeight(u.n), "union height")
+		s.setRoot(u.n)
+		checkRedBlack(t, s.root)
+	}
+}
+
+func TestJoinOutOfOrder(t *testing.T) {
+	defer func() {
+		testAssert(t, recover() != nil, "expected panic")
+	}()
+	a := NewSet[int]()
+	a.Insert(5)
+	Join(a, 3, NewSet[int]())
+}
+
+func TestJoinDifferentConfigurations(t *testing.T) {
+	sum := WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter))
+	max := WithAugmenter[int](AugmenterFunc[int, maxEnd[int]](func(l maxEnd[int], item int, r maxEnd[int]) maxEnd[int] {
+		return maxEnd[int]{item, true}
+	}))
+	for name, opts := range map[string][2]Option[int]{
+		"augmented/plain": {sum, func(*Set[int]) {}},
+		"plain/augmented": {func(*Set[int]) {}, sum},
+		"summary types":   {sum, max},
+		"duplicates":      {WithDuplicates[int](), func(*Set[int]) {}},
+		"iterator checks": {func(*Set[int]) {}, WithIteratorChecks[int]()},
+	} {
+		a, b := NewSet(opts[0]), NewSet(opts[1])
+		a.Insert(1)
+		b.Insert(2)
+		testPanics(t, func() { Union(a, b, nil) }, "different configurations")
+		testPanics(t, func() { Join(a, 5, b) }, "different configurations")
+		testAssert(t, a.Len() == 1 && b.Len() == 1, name+": trees left alone")
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 300; i++ {
+		maxKey := 1 + r.Intn(300)
+		for _, op := range []string{"union", "intersection", "difference", "symmetric"} {
+			a, akeys := newRandomSumSet(r, r.Intn(200), maxKey)
+			b, bkeys := newRandomSumSet(r, r.Intn(200), maxKey)
+			want := map[int]bool{}
+			var got *Set[int]
+			switch op {
+			case "union":
+				got = Union(a, b, nil)
+				for k := range akeys {
+					want[k] = true
+				}
+				for k := range bkeys {
+					want[k] = true
+				}
+			case "intersection":
+				got = Intersection(a, b, nil)
+				for k := range akeys {
+					if bkeys[k] {
+						want[k] = true
+					}
+				}
+			case "difference":
+				got = Difference(a, b)
+				for k := range akeys {
+					if !bkeys[k] {
+						want[k] = true
+					}
+				}
+			case "symmetric":
+				got = SymmetricDifference(a, b)
+				for k := range akeys {
+					if !bkeys[k] {
+						want[k] = true
+					}
+				}
+				for k := range bkeys {
+					if !akeys[k] {
+						want[k] = true
+					}
+				}
+			}
+			checkSetContents(t, got, want)
+			testAssert(t, a.Len() == 0 && b.Len() == 0, op+": inputs not emptied")
+		}
+	}
+}
+
+func TestUnionMerge(t *testing.T) {
+	a, b := newKVSet(), newKVSet()
+	a.Insert(kv{1, "a1"})
+	a.Insert(kv{2, "a2"})
+	b.Insert(kv{2, "b2"})
+	b.Insert(kv{3, "b3"})
+	u := Union(a, b, func(x, y kv) kv { return kv{x.key, x.value + "+" + y.value} })
+	testAssert(t, u.Len() == 3, "len")
+	testAssert(t, u.Get(kv{key: 2}).value == "a2+b2", "merged")
+	testAssert(t, u.Get(kv{key: 3}).value == "b3", "from b")
+}
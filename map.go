@@ -0,0 +1,187 @@
+package rbtree
+
+import "cmp"
+
+// Map is a red-black tree that maps keys of type K to values of type V,
+// ordered by the keys.
+type Map[K, V any] struct {
+	tree *Set[mapEntry[K, V]]
+}
+
+type mapEntry[K, V any] struct {
+	key   K
+	value V
+}
+
+// MapOption configures a map when it is created.
+type MapOption[K, V any] func(*Set[mapEntry[K, V]])
+
+// Create a new empty map. compare returns 0 if a==b, <0 if a<b, >0 if
+// a>b.
+func NewMapFunc[K, V any](compare func(a, b K) int, opts ...MapOption[K, V]) *Map[K, V] {
+	return newMap(compare, opts...)
+}
+
+func newMap[K, V any](compare func(a, b K) int, opts ...MapOption[K, V]) *Map[K, V] {
+	tree := NewSetFunc(func(a, b mapEntry[K, V]) int {
+		return compare(a.key, b.key)
+	})
+	for _, opt := range opts {
+		opt(tree)
+	}
+	return &Map[K, V]{tree: tree}
+}
+
+// Create a new empty map ordered by the natural order of K.
+func NewMap[K cmp.Ordered, V any](opts ...MapOption[K, V]) *Map[K, V] {
+	return NewMapFunc(cmp.Compare[K], opts...)
+}
+
+// Return the number of elements in the map.
+func (m *Map[K, V]) Len() int {
+	return m.tree.Len()
+}
+
+// Find the value for key. The 2nd return value is true iff the key
+// is in the map.
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	e, ok := m.tree.Lookup(mapEntry[K, V]{key: key})
+	return e.value, ok
+}
+
+// Insert a key and its value. If the key is already in the map, do
+// nothing and return false. Else return true. A map created by
+// NewMultiMap always inserts.
+func (m *Map[K, V]) Insert(key K, value V) bool {
+	return m.tree.Insert(mapEntry[K, V]{key, value})
+}
+
+// Delete the element with the given key. Return true iff the key was
+// found.
+func (m *Map[K, V]) DeleteWithKey(key K) bool {
+	return m.tree.DeleteWithKey(mapEntry[K, V]{key: key})
+}
+
+// Delete the current element, and return an iterator to its successor.
+// See Set.DeleteWithIterator.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (m *Map[K, V]) DeleteWithIterator(iter MapIterator[K, V]) MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.DeleteWithIterator(iter.iter)}
+}
+
+// Like DeleteWithIterator, but return an error instead of panicking.
+// See Set.TryDeleteWithIterator.
+func (m *Map[K, V]) TryDeleteWithIterator(iter MapIterator[K, V]) (MapIterator[K, V], error) {
+	next, err := m.tree.TryDeleteWithIterator(iter.iter)
+	return MapIterator[K, V]{next}, err
+}
+
+// Create an iterator that points to the minimum key in the map. If
+// the map is empty, return Limit().
+func (m *Map[K, V]) Min() MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.Min()}
+}
+
+// Create an iterator that points to the maximum key in the map. If
+// the map is empty, return NegativeLimit().
+func (m *Map[K, V]) Max() MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.Max()}
+}
+
+// Create an iterator that points beyond the maximum key in the map.
+func (m *Map[K, V]) Limit() MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.Limit()}
+}
+
+// Create an iterator that points before the minimum key in the map.
+func (m *Map[K, V]) NegativeLimit() MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.NegativeLimit()}
+}
+
+// Find the smallest key N such that N >= key, and return the iterator
+// pointing to its element. If no such key is found, return Limit().
+func (m *Map[K, V]) FindGE(key K) MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.FindGE(mapEntry[K, V]{key: key})}
+}
+
+// Find the largest key N such that N <= key, and return the iterator
+// pointing to its element. If no such key is found, return
+// NegativeLimit().
+func (m *Map[K, V]) FindLE(key K) MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.FindLE(mapEntry[K, V]{key: key})}
+}
+
+// MapIterator allows scanning map elements in key order. It follows
+// the same invalidation rule as SetIterator.
+type MapIterator[K, V any] struct {
+	iter SetIterator[mapEntry[K, V]]
+}
+
+func (iter MapIterator[K, V]) Equal(iter2 MapIterator[K, V]) bool {
+	return iter.iter.Equal(iter2.iter)
+}
+
+// Check if the iterator points beyond the max key in the map
+func (iter MapIterator[K, V]) Limit() bool {
+	return iter.iter.Limit()
+}
+
+// Check if the iterator points before the minimum key in the map
+func (iter MapIterator[K, V]) NegativeLimit() bool {
+	return iter.iter.NegativeLimit()
+}
+
+// Check if the iterator points to the minimum key in the map
+func (iter MapIterator[K, V]) Min() bool {
+	return iter.iter.Min()
+}
+
+// Check if the iterator points to the maximum key in the map
+func (iter MapIterator[K, V]) Max() bool {
+	return iter.iter.Max()
+}
+
+// Return the key of the current element.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (iter MapIterator[K, V]) Key() K {
+	return iter.iter.Item().key
+}
+
+// Return the value of the current element.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (iter MapIterator[K, V]) Value() V {
+	return iter.iter.Item().value
+}
+
+// Create a new iterator that points to the successor of the current
+// element.
+//
+// REQUIRES: !iter.Limit()
+func (iter MapIterator[K, V]) Next() MapIterator[K, V] {
+	return MapIterator[K, V]{iter.iter.Next()}
+}
+
+// Like Next, but return an error instead of panicking. See
+// SetIterator.TryNext.
+func (iter MapIterator[K, V]) TryNext() (MapIterator[K, V], error) {
+	next, err := iter.iter.TryNext()
+	return MapIterator[K, V]{next}, err
+}
+
+// Create a new iterator that points to the predecessor of the current
+// element.
+//
+// REQUIRES: !iter.NegativeLimit()
+func (iter MapIterator[K, V]) Prev() MapIterator[K, V] {
+	return MapIterator[K, V]{iter.iter.Prev()}
+}
+
+// Like Prev, but return an error instead of panicking. See
+// SetIterator.TryPrev.
+func (iter MapIterator[K, V]) TryPrev() (MapIterator[K, V], error) {
+	prev, err := iter.iter.TryPrev()
+	return MapIterator[K, V]{prev}, err
+}
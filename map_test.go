@@ -0,0 +1,83 @@
+package rbtree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSetOrdered(t *testing.T) {
+	s := NewSet[string]()
+	testAssert(t, s.Insert("b"), "insert b")
+	testAssert(t, s.Insert("a"), "insert a")
+	testAssert(t, !s.Insert("a"), "insert a twice")
+	testAssert(t, s.Len() == 2, "len")
+	testAssert(t, s.Min().Item() == "a", "min")
+	testAssert(t, s.Max().Item() == "b", "max")
+	testAssert(t, s.FindGE("aa").Item() == "b", "FindGE")
+	testAssert(t, s.FindLE("aa").Item() == "a", "FindLE")
+	testAssert(t, s.FindLE("0").NegativeLimit(), "FindLE 0")
+	_, ok := s.Lookup("c")
+	testAssert(t, !ok, "Lookup c")
+	item, ok := s.Lookup("b")
+	testAssert(t, ok && item == "b", "Lookup b")
+	testAssert(t, s.Get("c") == "", "Get c")
+}
+
+func TestSetFunc(t *testing.T) {
+	s := NewSetFunc(func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	s.Insert("Foo")
+	testAssert(t, !s.Insert("foo"), "case-insensitive dup")
+	testAssert(t, s.Get("FOO") == "Foo", "Get FOO")
+}
+
+func TestMap(t *testing.T) {
+	m := NewMap[int, string]()
+	_, ok := m.Get(10)
+	testAssert(t, !ok, "Get on empty map")
+	testAssert(t, m.Max().NegativeLimit(), "Max on empty map")
+	testAssert(t, m.Min().Limit(), "Min on empty map")
+	for i := 0; i < 10; i += 2 {
+		testAssert(t, m.Insert(i, fmt.Sprint("v", i)), "insert")
+	}
+	testAssert(t, !m.Insert(4, "dup"), "insert dup")
+	v, ok := m.Get(4)
+	testAssert(t, ok && v == "v4", "Get 4")
+	_, ok = m.Get(5)
+	testAssert(t, !ok, "Get 5")
+
+	s := ""
+	for it := m.FindGE(3); !it.Limit(); it = it.Next() {
+		s += fmt.Sprintf("%d=%s,", it.Key(), it.Value())
+	}
+	testAssert(t, s == "4=v4,6=v6,8=v8,", s)
+	s = ""
+	for it := m.FindLE(3); !it.NegativeLimit(); it = it.Prev() {
+		s += fmt.Sprintf("%d,", it.Key())
+	}
+	testAssert(t, s == "2,0,", s)
+
+	testAssert(t, m.DeleteWithKey(4), "delete 4")
+	testAssert(t, !m.DeleteWithKey(4), "delete 4 again")
+	m.DeleteWithIterator(m.Min())
+	testAssert(t, m.Len() == 3, "len")
+	testAssert(t, m.Min().Key() == 2, "min")
+}
+
+func ExampleMap() {
+	m := NewMap[int, string]()
+	m.Insert(10, "value10")
+	m.Insert(12, "value12")
+
+	fmt.Println(m.Get(10))
+	fmt.Println(m.Get(11))
+	iter := m.FindGE(11)
+	fmt.Println("FindGE(11) ->", iter.Key(), iter.Value())
+
+	// Output:
+	// value10 true
+	//  false
+	// FindGE(11) -> 12 value12
+}
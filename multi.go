@@ -0,0 +1,71 @@
+package rbtree
+
+import "cmp"
+
+// Allow the tree to hold several items that compare equal. Equal items
+// are kept in insertion order, and lookups such as Get, FindGE and
+// DeleteWithKey act on the first of them.
+func WithDuplicates[T any]() Option[T] {
+	return func(root *Set[T]) {
+		root.multi = true
+	}
+}
+
+// Create a new empty tree that allows duplicate items.
+func NewMultiTree(compare CompareFunc, opts ...Option[Item]) *Tree {
+	return NewTree(compare, append(opts, WithDuplicates[Item]())...)
+}
+
+// Return iterators to the first item equal to key and to the first
+// item greater than key. The two are equal if there is no such item.
+func (root *Set[T]) EqualRange(key T) (first, limit SetIterator[T]) {
+	n, _ := root.findGE(key)
+	return root.iterator(n), root.iterator(root.findGT(key))
+}
+
+// Return the number of items equal to key.
+func (root *Set[T]) Count(key T) int {
+	first, limit := root.EqualRange(key)
+	return limit.Index() - first.Index()
+}
+
+// Delete all items equal to key, and return the number deleted.
+func (root *Set[T]) DeleteAllWithKey(key T) int {
+	deleted := 0
+	for root.DeleteWithKey(key) {
+		deleted++
+	}
+	return deleted
+}
+
+// Create a new empty map that allows duplicate keys. compare returns 0
+// if a==b, <0 if a<b, >0 if a>b.
+func NewMultiMapFunc[K, V any](compare func(a, b K) int, opts ...MapOption[K, V]) *Map[K, V] {
+	m := newMap(compare, opts...)
+	m.tree.multi = true
+	return m
+}
+
+// Create a new empty map that allows duplicate keys, ordered by the
+// natural order of K.
+func NewMultiMap[K cmp.Ordered, V any](opts ...MapOption[K, V]) *Map[K, V] {
+	return NewMultiMapFunc(cmp.Compare[K], opts...)
+}
+
+// Return iterators to the first element with the given key and to the
+// first element with a greater key.
+func (m *Map[K, V]) EqualRange(key K) (first, limit MapIterator[K, V]) {
+	f, l := m.tree.EqualRange(mapEntry[K, V]{key: key})
+	return MapIterator[K, V]{f}, MapIterator[K, V]{l}
+}
+
+// Return the number of elements with the given key.
+func (m *Map[K, V]) Count(key K) int {
+	return m.tree.Count(mapEntry[K, V]{key: key})
+}
+
+// Delete all elements with the given key, and return the number
+// deleted.
+func (m *Map[K, V]) DeleteAllWithKey(key K) int {
+	return m.tree.DeleteAllWithKey(mapEntry[K, V]{key: key})
+}
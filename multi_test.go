@@ -0,0 +1,108 @@
+package rbtree
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type multiItem struct {
+	key, seq int
+}
+
+func newMultiItemSet() *Tree {
+	return NewMultiTree(func(a, b Item) int {
+		return a.(multiItem).key - b.(multiItem).key
+	})
+}
+
+func TestMultiTree(t *testing.T) {
+	tree := newMultiItemSet()
+	for i, key := range []int{5, 3, 5, 7, 5, 3} {
+		testAssert(t, tree.Insert(multiItem{key, i}), "insert")
+	}
+	testAssert(t, tree.Len() == 6, "len")
+	testAssert(t, tree.Count(multiItem{key: 5}) == 3, "count 5")
+	testAssert(t, tree.Count(multiItem{key: 4}) == 0, "count 4")
+	testAssert(t, tree.Get(multiItem{key: 5}).(multiItem).seq == 0, "get first")
+	testAssert(t, tree.Max().Item().(multiItem).key == 7, "max")
+
+	first, limit := tree.EqualRange(multiItem{key: 5})
+	s := ""
+	for it := first; !it.Equal(limit); it = it.Next() {
+		s += fmt.Sprint(it.Item().(multiItem).seq)
+	}
+	testAssert(t, s == "024", "insertion order: "+s)
+	testAssert(t, limit.Item().(multiItem).key == 7, "limit")
+	first, limit = tree.EqualRange(multiItem{key: 4})
+	testAssert(t, first.Equal(limit), "empty range")
+
+	testAssert(t, tree.FindLE(multiItem{key: 5}).Item().(multiItem).seq == 4, "FindLE last equal")
+	testAssert(t, tree.DeleteWithKey(multiItem{key: 5}), "delete one")
+	testAssert(t, tree.Get(multiItem{key: 5}).(multiItem).seq == 2, "get after delete one")
+	testAssert(t, tree.DeleteAllWithKey(multiItem{key: 5}) == 2, "delete all")
+	testAssert(t, tree.Count(multiItem{key: 5}) == 0, "count after delete all")
+	testAssert(t, tree.Len() == 3, "len after delete all")
+}
+
+func TestMultiTreeRandomized(t *testing.T) {
+	const numKeys = 50
+	tree := newMultiItemSet()
+	var model []multiItem
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 3000; i++ {
+		key := r.Intn(numKeys)
+		switch op := r.Intn(10); {
+		case op < 6:
+			tree.Insert(multiItem{key, i})
+			model = append(model, multiItem{key, i})
+			sort.SliceStable(model, func(a, b int) bool { return model[a].key < model[b].key })
+		case op < 9:
+			found := false
+			for j, item := range model {
+				if item.key == key {
+					model = append(model[:j], model[j+1:]...)
+					found = true
+					break
+				}
+			}
+			testAssert(t, tree.DeleteWithKey(multiItem{key: key}) == found, "delete")
+		default:
+			n := 0
+			for j := 0; j < len(model); j++ {
+				if model[j].key == key {
+					model = append(model[:j], model[j+1:]...)
+					j--
+					n++
+				}
+			}
+			testAssert(t, tree.DeleteAllWithKey(multiItem{key: key}) == n, "delete all")
+		}
+		testAssert(t, tree.Len() == len(model), "len")
+		j := 0
+		for item := range tree.All() {
+			if item.(multiItem) != model[j] {
+				t.Fatalf("item %d: got %v, want %v", j, item, model[j])
+			}
+			j++
+		}
+		if len(model) > 0 {
+			testAssert(t, tree.Min().Item() == model[0], "min")
+			testAssert(t, tree.Max().Item() == model[len(model)-1], "max")
+		}
+	}
+}
+
+func TestMultiMap(t *testing.T) {
+	m := NewMultiMap[string, int]()
+	testAssert(t, m.Insert("a", 1), "insert")
+	testAssert(t, m.Insert("a", 2), "insert dup")
+	m.Insert("b", 3)
+	testAssert(t, m.Count("a") == 2, "count")
+	first, limit := m.EqualRange("a")
+	testAssert(t, first.Value() == 1 && first.Next().Value() == 2, "order")
+	testAssert(t, limit.Key() == "b", "limit")
+	testAssert(t, m.DeleteAllWithKey("a") == 2, "delete all")
+	testAssert(t, m.Len() == 1, "len")
+}
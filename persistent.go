@@ -0,0 +1,632 @@
+package rbtree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// PersistentTree is a PersistentSet of untyped Items.
+type PersistentTree = PersistentSet[Item]
+
+// PersistentSet is a red-black tree that never modifies a node once it
+// is part of a tree. An update copies the O(log n) nodes on the path it
+// changes and shares everything else with the previous version, so
+// Snapshot is O(1) and a snapshot is unaffected by later updates to the
+// tree it was taken from.
+//
+// Nodes have no parent pointers, since a shared node can have many
+// parents. Iterators instead carry the path from the root.
+//
+// A PersistentSet itself is not safe for concurrent use, but a snapshot
+// can be read from other goroutines while the original is modified.
+type PersistentSet[T any] struct {
+	root    *pnode[T]
+	compare func(a, b T) int
+}
+
+type pnode[T any] struct {
+	item        T
+	left, right *pnode[T]
+	color       int // black or red
+	size        int // number of nodes in this subtree
+}
+
+// Create a new empty persistent tree.
+func NewPersistentTree(compare CompareFunc) *PersistentTree {
+	return NewPersistentSetFunc[Item](compare)
+}
+
+// Create a new empty persistent set. compare returns 0 if a==b, <0 if
+// a<b, >0 if a>b.
+func NewPersistentSetFunc[T any](compare func(a, b T) int) *PersistentSet[T] {
+	return &PersistentSet[T]{compare: compare}
+}
+
+// Create a new empty persistent set ordered by the natural order of T.
+func NewPersistentSet[T cmp.Ordered]() *PersistentSet[T] {
+	return NewPersistentSetFunc(cmp.Compare[T])
+}
+
+// Return an independent copy of the tree in O(1) time.
+func (s *PersistentSet[T]) Snapshot() *PersistentSet[T] {
+	return &PersistentSet[T]{root: s.root, compare: s.compare}
+}
+
+// Return the number of elements in the tree.
+func (s *PersistentSet[T]) Len() int {
+	return getPSize(s.root)
+}
+
+// A convenience function for finding an element equal to key. Return
+// the zero value of T if not found.
+func (s *PersistentSet[T]) Get(key T) T {
+	item, _ := s.Lookup(key)
+	return item
+}
+
+// Find an element equal to key. The 2nd return value is true iff such
+// an element exists.
+func (s *PersistentSet[T]) Lookup(key T) (T, bool) {
+	return plookup(s.root, s.compare, key)
+}
+
+// Insert an item. If the item is already in the tree, do nothing and
+// return false. Else return true.
+func (s *PersistentSet[T]) Insert(item T) bool {
+	root, ok := pinsert(s.root, s.compare, item)
+	s.root = root
+	return ok
+}
+
+// Delete an item with the given key. Return true iff the item was
+// found.
+func (s *PersistentSet[T]) DeleteWithKey(key T) bool {
+	root, ok := pdelete(s.root, s.compare, key)
+	s.root = root
+	return ok
+}
+
+// Create an iterator that points to the minimum item in the tree. If
+// the tree is empty, return Limit().
+func (s *PersistentSet[T]) Min() PersistentIterator[T] {
+	return PersistentIterator[T]{root: s.root}.leftmost(s.root)
+}
+
+// Create an iterator that points at the maximum item in the tree. If
+// the tree is empty, return NegativeLimit().
+func (s *PersistentSet[T]) Max() PersistentIterator[T] {
+	return PersistentIterator[T]{root: s.root}.rightmost(s.root)
+}
+
+// Create an iterator that points beyond the maximum item in the tree.
+func (s *PersistentSet[T]) Limit() PersistentIterator[T] {
+	return PersistentIterator[T]{root: s.root}
+}
+
+// Create an iterator that points before the minimum item in the tree.
+func (s *PersistentSet[T]) NegativeLimit() PersistentIterator[T] {
+	return PersistentIterator[T]{root: s.root, negative: true}
+}
+
+// Find the smallest element N such that N >= key, and return the
+// iterator pointing to the element. If no such element is found, return
+// Limit().
+func (s *PersistentSet[T]) FindGE(key T) PersistentIterator[T] {
+	iter := PersistentIterator[T]{root: s.root}
+	best := 0
+	for n := s.root; n != nil; {
+		iter.path = append(iter.path, n)
+		comp := s.compare(key, n.item)
+		if comp <= 0 {
+			best = len(iter.path)
+			if comp == 0 {
+				break
+			}
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	iter.path = iter.path[:best]
+	return iter
+}
+
+// Find the largest element N such that N <= key, and return the
+// iterator pointing to the element. If no such element is found, return
+// NegativeLimit().
+func (s *PersistentSet[T]) FindLE(key T) PersistentIterator[T] {
+	iter := PersistentIterator[T]{root: s.root}
+	best := 0
+	for n := s.root; n != nil; {
+		iter.path = append(iter.path, n)
+		comp := s.compare(key, n.item)
+		if comp >= 0 {
+			best = len(iter.path)
+			if comp == 0 {
+				break
+			}
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	iter.path = iter.path[:best]
+	iter.negative = best == 0
+	return iter
+}
+
+// Return a sequence of all items in ascending order.
+func (s *PersistentSet[T]) All() iter.Seq[T] {
+	root := s.root
+	return func(yield func(T) bool) {
+		var stack []*pnode[T]
+		for n := root; n != nil || len(stack) > 0; n = n.right {
+			for ; n != nil; n = n.left {
+				stack = append(stack, n)
+			}
+			n = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(n.item) {
+				return
+			}
+		}
+	}
+}
+
+// Return a sequence of all items in descending order.
+func (s *PersistentSet[T]) Backward() iter.Seq[T] {
+	root := s.root
+	return func(yield func(T) bool) {
+		var stack []*pnode[T]
+		for n := root; n != nil || len(stack) > 0; n = n.left {
+			for ; n != nil; n = n.right {
+				stack = append(stack, n)
+			}
+			n = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !yield(n.item) {
+				return
+			}
+		}
+	}
+}
+
+// PersistentIterator allows scanning a PersistentSet in sort order. It
+// refers to the version of the tree it was created from, so it stays
+// valid however the tree is modified afterwards.
+type PersistentIterator[T any] struct {
+	root *pnode[T]
+	// Path from the root to the current node. Empty at the limits.
+	path     []*pnode[T]
+	negative bool
+}
+
+func (iter PersistentIterator[T]) Equal(iter2 PersistentIterator[T]) bool {
+	return iter.node() == iter2.node() && iter.negative == iter2.negative
+}
+
+// Check if the iterator points beyond the max element in the tree
+func (iter PersistentIterator[T]) Limit() bool {
+	return len(iter.path) == 0 && !iter.negative
+}
+
+// Check if the iterator points before the minimum element in the tree
+func (iter PersistentIterator[T]) NegativeLimit() bool {
+	return iter.negative
+}
+
+// Return the current element.
+//
+// REQUIRES: !iter.Limit() && !iter.NegativeLimit()
+func (iter PersistentIterator[T]) Item() T {
+	n := iter.node()
+	if n == nil {
+		panic(ErrIteratorAtLimit)
+	}
+	return n.item
+}
+
+// Create a new iterator that points to the successor of the current
+// element.
+//
+// REQUIRES: !iter.Limit()
+func (iter PersistentIterator[T]) Next() PersistentIterator[T] {
+	if iter.Limit() {
+		panic(ErrIteratorAtLimit)
+	}
+	if iter.negative {
+		return PersistentIterator[T]{root: iter.root}.leftmost(iter.root)
+	}
+	next := iter
+	if n := next.node(); n.right != nil {
+		return next.leftmost(n.right)
+	}
+	for len(next.path) > 0 {
+		n := next.node()
+		next.path = next.path[:len(next.path)-1]
+		if p := next.node(); p != nil && p.left == n {
+			break
+		}
+	}
+	return next
+}
+
+// Create a new iterator that points to the predecessor of the current
+// element.
+//
+// REQUIRES: !iter.NegativeLimit()
+func (iter PersistentIterator[T]) Prev() PersistentIterator[T] {
+	if iter.NegativeLimit() {
+		panic(ErrIteratorAtLimit)
+	}
+	if iter.Limit() {
+		return PersistentIterator[T]{root: iter.root}.rightmost(iter.root)
+	}
+	prev := iter
+	if n := prev.node(); n.left != nil {
+		return prev.rightmost(n.left)
+	}
+	for len(prev.path) > 0 {
+		n := prev.node()
+		prev.path = prev.path[:len(prev.path)-1]
+		if p := prev.node(); p != nil && p.right == n {
+			return prev
+		}
+	}
+	prev.negative = true
+	return prev
+}
+
+// Return the current node, or nil at the limits.
+func (iter PersistentIterator[T]) node() *pnode[T] {
+	if len(iter.path) == 0 {
+		return nil
+	}
+	return iter.path[len(iter.path)-1]
+}
+
+// Extend the path to the minimum node under n. If n is nil, return
+// Limit().
+func (iter PersistentIterator[T]) leftmost(n *pnode[T]) PersistentIterator[T] {
+	iter.path = clipPath(iter.path)
+	for ; n != nil; n = n.left {
+		iter.path = append(iter.path, n)
+	}
+	return iter
+}
+
+// Extend the path to the maximum node under n. If the path ends up
+// empty, return NegativeLimit().
+func (iter PersistentIterator[T]) rightmost(n *pnode[T]) PersistentIterator[T] {
+	iter.path = clipPath(iter.path)
+	for ; n != nil; n = n.right {
+		iter.path = append(iter.path, n)
+	}
+	iter.negative = len(iter.path) == 0
+	return iter
+}
+
+// Copies of an iterator share the array under their paths. Clip path
+// so that extending it allocates a new array instead of overwriting
+// the nodes that another copy may still refer to.
+func clipPath[T any](path []*pnode[T]) []*pnode[T] {
+	return path[:len(path):len(path)]
+}
+
+//
+// Path-copying updates. Each returns a new root and leaves the old
+// version intact. Nodes on the path from the root are cloned before
+// being modified; so is any sibling or nephew that the rebalancing
+// recolors or rotates.
+//
+
+func getPSize[T any](n *pnode[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func getPColor[T any](n *pnode[T]) int {
+	if n == nil {
+		return black
+	}
+	return n.color
+}
+
+func (n *pnode[T]) clone() *pnode[T] {
+	c := *n
+	return &c
+}
+
+func (n *pnode[T]) updateSize() {
+	n.size = getPSize(n.left) + getPSize(n.right) + 1
+}
+
+func plookup[T any](n *pnode[T], compare func(a, b T) int, key T) (T, bool) {
+	for n != nil {
+		comp := compare(key, n.item)
+		if comp == 0 {
+			return n.item, true
+		} else if comp < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// pathEditor holds a partially built new version of a tree: the new
+// root, and the cloned nodes on the path from it to the node being
+// worked on.
+type pathEditor[T any] struct {
+	root *pnode[T]
+	path []*pnode[T]
+}
+
+// Return path[i], or nil if i is out of range.
+func (e *pathEditor[T]) at(i int) *pnode[T] {
+	if i < 0 || i >= len(e.path) {
+		return nil
+	}
+	return e.path[i]
+}
+
+// Make newChild take oldChild's place under parent (or at the root if
+// parent is nil).
+func (e *pathEditor[T]) replaceChild(parent, oldChild, newChild *pnode[T]) {
+	if parent == nil {
+		e.root = newChild
+	} else if parent.left == oldChild {
+		parent.left = newChild
+	} else {
+		parent.right = newChild
+	}
+}
+
+// Clone the left or right child of parent in place and return it.
+func cloneLeft[T any](parent *pnode[T]) *pnode[T] {
+	parent.left = parent.left.clone()
+	return parent.left
+}
+
+func cloneRight[T any](parent *pnode[T]) *pnode[T] {
+	parent.right = parent.right.clone()
+	return parent.right
+}
+
+// Rotate left at n, whose right child must already be a clone.
+func (e *pathEditor[T]) rotateLeft(n, parent *pnode[T]) {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	e.replaceChild(parent, n, r)
+	n.updateSize()
+	r.updateSize()
+}
+
+// Rotate right at n, whose left child must already be a clone.
+func (e *pathEditor[T]) rotateRight(n, parent *pnode[T]) {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	e.replaceChild(parent, n, l)
+	n.updateSize()
+	l.updateSize()
+}
+
+func pinsert[T any](root *pnode[T], compare func(a, b T) int, item T) (*pnode[T], bool) {
+	e := &pathEditor[T]{}
+	var parent *pnode[T]
+	n := root
+	comp := 0
+	for n != nil {
+		comp = compare(item, n.item)
+		if comp == 0 {
+			return root, false
+		}
+		c := n.clone()
+		c.size++
+		e.replaceChild(parent, n, c)
+		e.path = append(e.path, c)
+		parent = c
+		if comp < 0 {
+			n = c.left
+		} else {
+			n = c.right
+		}
+	}
+	leaf := &pnode[T]{item: item, color: red, size: 1}
+	if parent == nil {
+		e.root = leaf
+	} else if comp < 0 {
+		parent.left = leaf
+	} else {
+		parent.right = leaf
+	}
+	e.path = append(e.path, leaf)
+	e.insertFixup()
+	return e.root, true
+}
+
+// The same cases as Set.insertFixup, with the path standing in for
+// parent pointers.
+func (e *pathEditor[T]) insertFixup() {
+	i := len(e.path) - 1
+	for {
+		n := e.path[i]
+		p := e.at(i - 1)
+		if p == nil {
+			n.color = black
+			return
+		}
+		if p.color == black {
+			return
+		}
+		g, gg := e.at(i-2), e.at(i-3)
+		if p == g.left && getPColor(g.right) == red {
+			u := cloneRight(g)
+			p.color, u.color, g.color = black, black, red
+			i -= 2
+			continue
+		}
+		if p == g.right && getPColor(g.left) == red {
+			u := cloneLeft(g)
+			p.color, u.color, g.color = black, black, red
+			i -= 2
+			continue
+		}
+		if p == g.left {
+			if n == p.right {
+				e.rotateLeft(p, g)
+				p = n
+			}
+			e.rotateRight(g, gg)
+		} else {
+			if n == p.left {
+				e.rotateRight(p, g)
+				p = n
+			}
+			e.rotateLeft(g, gg)
+		}
+		p.color, g.color = black, red
+		return
+	}
+}
+
+func pdelete[T any](root *pnode[T], compare func(a, b T) int, key T) (*pnode[T], bool) {
+	// Find the node, without copying anything in case it is not there.
+	n := root
+	for n != nil {
+		comp := compare(key, n.item)
+		if comp == 0 {
+			break
+		} else if comp < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if n == nil {
+		return root, false
+	}
+
+	// Copy the path to the node, and on to its predecessor if it has
+	// two children. The predecessor's item then moves into the copy of
+	// the node, and the predecessor is removed instead.
+	e := &pathEditor[T]{}
+	var parent, target *pnode[T]
+	n = root
+	for {
+		c := n.clone()
+		c.size--
+		e.replaceChild(parent, n, c)
+		e.path = append(e.path, c)
+		parent = c
+		if target == nil {
+			comp := compare(key, c.item)
+			if comp == 0 {
+				target = c
+				if c.left == nil || c.right == nil {
+					break
+				}
+				n = c.left
+			} else if comp < 0 {
+				n = c.left
+			} else {
+				n = c.right
+			}
+		} else if c.right != nil {
+			n = c.right
+		} else {
+			break
+		}
+	}
+	y := e.path[len(e.path)-1]
+	target.item = y.item
+
+	// Splice y out. Its child, if any, is cloned so that the fixup can
+	// recolor it.
+	e.path = e.path[:len(e.path)-1]
+	x := y.left
+	if x == nil {
+		x = y.right
+	}
+	if x != nil {
+		x = x.clone()
+	}
+	e.replaceChild(e.at(len(e.path)-1), y, x)
+	if y.color == black {
+		e.deleteFixup(x)
+	}
+	return e.root, true
+}
+
+// Restore the red-black properties after a black node was removed from
+// the position now held by x (possibly nil), whose parent is the last
+// node on the path. This follows the cases in CLRS.
+func (e *pathEditor[T]) deleteFixup(x *pnode[T]) {
+	i := len(e.path) - 1 // index of x's parent
+	for i >= 0 && getPColor(x) == black {
+		p, pp := e.path[i], e.at(i-1)
+		if x == p.left {
+			w := cloneRight(p)
+			if w.color == red {
+				w.color, p.color = black, red
+				e.rotateLeft(p, pp)
+				// w is now p's parent.
+				e.path = slices.Insert(e.path, i, w)
+				i++
+				pp = w
+				w = cloneRight(p)
+			}
+			if getPColor(w.left) == black && getPColor(w.right) == black {
+				w.color = red
+				x = p
+				i--
+				continue
+			}
+			if getPColor(w.right) == black {
+				wl := cloneLeft(w)
+				wl.color, w.color = black, red
+				e.rotateRight(w, p)
+				w = wl
+			}
+			w.color, p.color = p.color, black
+			cloneRight(w).color = black
+			e.rotateLeft(p, pp)
+		} else {
+			w := cloneLeft(p)
+			if w.color == red {
+				w.color, p.color = black, red
+				e.rotateRight(p, pp)
+				e.path = slices.Insert(e.path, i, w)
+				i++
+				pp = w
+				w = cloneLeft(p)
+			}
+			if getPColor(w.left) == black && getPColor(w.right) == black {
+				w.color = red
+				x = p
+				i--
+				continue
+			}
+			if getPColor(w.left) == black {
+				wr := cloneRight(w)
+				wr.color, w.color = black, red
+				e.rotateLeft(w, p)
+				w = wr
+			}
+			w.color, p.color = p.color, black
+			cloneLeft(w).color = black
+			e.rotateRight(p, pp)
+		}
+		return
+	}
+	if x != nil {
+		x.color = black
+	}
+}
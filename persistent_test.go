@@ -0,0 +1,138 @@
+package rbtree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// Check the red-black properties and sizes of the subtree at n, and
+// return its black height.
+func checkPersistent[T any](t *testing.T, n *pnode[T]) int {
+	if n == nil {
+		return 1
+	}
+	if n.color == red && (getPColor(n.left) == red || getPColor(n.right) == red) {
+		t.Fatalf("red node %v has a red child", n.item)
+	}
+	if n.size != getPSize(n.left)+getPSize(n.right)+1 {
+		t.Fatalf("node %v: bad size %d", n.item, n.size)
+	}
+	lh := checkPersistent(t, n.left)
+	if lh != checkPersistent(t, n.right) {
+		t.Fatalf("node %v: black heights differ", n.item)
+	}
+	if n.color == black {
+		lh++
+	}
+	return lh
+}
+
+func TestPersistentBasic(t *testing.T) {
+	s := NewPersistentSet[int]()
+	testAssert(t, s.Min().Limit(), "empty min")
+	testAssert(t, s.Max().NegativeLimit(), "empty max")
+	testAssert(t, s.FindGE(10).Limit(), "empty FindGE")
+	testAssert(t, s.FindLE(10).NegativeLimit(), "empty FindLE")
+	for i := 0; i < 10; i += 2 {
+		testAssert(t, s.Insert(i), "insert")
+	}
+	testAssert(t, !s.Insert(4), "insert dup")
+	testAssert(t, s.Len() == 5, "len")
+	testAssert(t, s.FindGE(3).Item() == 4, "FindGE 3")
+	testAssert(t, s.FindLE(3).Item() == 2, "FindLE 3")
+	testAssert(t, s.FindLE(-1).NegativeLimit(), "FindLE -1")
+	testAssert(t, s.FindGE(9).Limit(), "FindGE 9")
+
+	snap := s.Snapshot()
+	testAssert(t, s.DeleteWithKey(4), "delete")
+	testAssert(t, !s.DeleteWithKey(4), "delete again")
+	s.Insert(5)
+	testAssert(t, slices.Equal(slices.Collect(s.All()), []int{0, 2, 5, 6, 8}), "current")
+	testAssert(t, slices.Equal(slices.Collect(snap.All()), []int{0, 2, 4, 6, 8}), "snapshot")
+	testAssert(t, slices.Equal(slices.Collect(snap.Backward()), []int{8, 6, 4, 2, 0}), "snapshot backward")
+
+	// An iterator keeps seeing the version it was created from.
+	it := s.Min()
+	s.DeleteWithKey(2)
+	got := []int{}
+	for ; !it.Limit(); it = it.Next() {
+		got = append(got, it.Item())
+	}
+	testAssert(t, slices.Equal(got, []int{0, 2, 5, 6, 8}), "old iterator")
+	got = got[:0]
+	for it = s.Max(); !it.NegativeLimit(); it = it.Prev() {
+		got = append(got, it.Item())
+	}
+	testAssert(t, slices.Equal(got, []int{8, 6, 5, 0}), "reverse")
+	testAssert(t, s.NegativeLimit().Next().Item() == 0, "NegativeLimit.Next")
+	testAssert(t, s.Limit().Prev().Item() == 8, "Limit.Prev")
+}
+
+func TestPersistentIteratorCopies(t *testing.T) {
+	s := NewPersistentSet[int]()
+	for i := 0; i < 1000; i++ {
+		s.Insert(i * 10)
+	}
+	// Advancing a copy of an iterator leaves the original in place.
+	start := s.FindGE(10)
+	it := start
+	for i := 0; i < 20; i++ {
+		it = it.Next()
+	}
+	testAssert(t, start.Item() == 10 && it.Item() == 210, "copy advanced by Next")
+	back := start.Prev().Prev()
+	testAssert(t, back.NegativeLimit() && start.Item() == 10 && it.Item() == 210, "copy advanced by Prev")
+	testAssert(t, start.Next().Item() == 20 && start.Prev().Item() == 0, "original can be advanced")
+
+	testPanics(t, func() { s.Limit().Next() }, ErrIteratorAtLimit.Error())
+	testPanics(t, func() { s.NegativeLimit().Prev() }, ErrIteratorAtLimit.Error())
+	testPanics(t, func() { s.Limit().Item() }, ErrIteratorAtLimit.Error())
+}
+
+func TestPersistentRandomized(t *testing.T) {
+	const numKeys = 300
+	type version struct {
+		snap *PersistentSet[int]
+		keys []int
+	}
+	var versions []version
+	s := NewPersistentSet[int]()
+	keys := map[int]bool{}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(numKeys)
+		if r.Intn(5) < 2 {
+			testAssert(t, s.DeleteWithKey(key) == keys[key], "delete")
+			delete(keys, key)
+		} else {
+			testAssert(t, s.Insert(key) == !keys[key], "insert")
+			keys[key] = true
+		}
+		checkPersistent(t, s.root)
+		testAssert(t, getPColor(s.root) == black, "root color")
+		testAssert(t, s.Len() == len(keys), "len")
+
+		if i%100 == 0 {
+			sorted := []int{}
+			for k := range keys {
+				sorted = append(sorted, k)
+			}
+			sort.Ints(sorted)
+			versions = append(versions, version{s.Snapshot(), sorted})
+			for k := -1; k <= numKeys; k++ {
+				j := sort.SearchInts(sorted, k)
+				ge := s.FindGE(k)
+				testAssert(t, ge.Limit() == (j == len(sorted)), "FindGE limit")
+				if !ge.Limit() {
+					testAssert(t, ge.Item() == sorted[j], "FindGE")
+				}
+			}
+		}
+	}
+	for _, v := range versions {
+		testAssert(t, slices.Equal(slices.Collect(v.snap.All()), v.keys), "snapshot changed")
+		checkPersistent(t, v.snap.root)
+	}
+}
@@ -0,0 +1,140 @@
+package rbtree
+
+// NodePool recycles the nodes of the trees that use it, to reduce
+// allocation and GC work in trees with a high rate of insertions and
+// deletions. Deleted nodes go on a free list and are reused by later
+// insertions. New nodes are carved out of slabs holding many nodes each,
+// so the number of allocations grows with the number of slabs rather
+// than the number of items. A slab is freed only when none of its nodes
+// is in use.
+//
+// A NodePool is not safe for concurrent use. It may be shared by several
+// trees holding the same item type as long as they are used from one
+// goroutine at a time, for example under the same lock.
+type NodePool[T any] struct {
+	// Nodes available for reuse, linked through their right pointers.
+	free *node[T]
+	// Unused nodes at the end of the current slab.
+	slab     []node[T]
+	slabSize int
+	// Number of nodes on the free list.
+	numFree int
+}
+
+// Used if the slab size passed to NewNodePool is not positive.
+const defaultSlabSize = 256
+
+// Create an empty pool that allocates nodes slabSize at a time.
+func NewNodePool[T any](slabSize int) *NodePool[T] {
+	if slabSize <= 0 {
+		slabSize = defaultSlabSize
+	}
+	return &NodePool[T]{slabSize: slabSize}
+}
+
+// Return an option that makes the tree allocate nodes from pool and
+// return deleted nodes to it. Trees produced from this one by Split,
+// Join and the set operations use the same pool, and the set operations
+// return to it the nodes they drop, including those of the other tree.
+// In a tree created WithAugmenter, a node's summary is recycled along
+// with it.
+func WithNodePool[T any](pool *NodePool[T]) Option[T] {
+	return func(root *Set[T]) {
+		root.pool = pool
+	}
+}
+
+// Delete all the items, invalidating all iterators. With a node pool,
+// the nodes are returned to it in O(n) time; otherwise this takes O(1)
+// time and leaves the nodes to the garbage collector.
+func (root *Set[T]) Clear() {
+	root.freeSubtree(root.root)
+	root.setRoot(nil)
+	root.invalidateIterators()
+}
+
+// MapNodePool recycles the nodes of the maps that use it. See NodePool.
+type MapNodePool[K, V any] struct {
+	pool *NodePool[mapEntry[K, V]]
+}
+
+// Create an empty pool that allocates nodes slabSize at a time.
+func NewMapNodePool[K, V any](slabSize int) *MapNodePool[K, V] {
+	return &MapNodePool[K, V]{NewNodePool[mapEntry[K, V]](slabSize)}
+}
+
+// Return an option that makes the map allocate nodes from pool and
+// return deleted nodes to it. See WithNodePool.
+func WithMapNodePool[K, V any](pool *MapNodePool[K, V]) MapOption[K, V] {
+	return MapOption[K, V](WithNodePool(pool.pool))
+}
+
+// Delete all the entries. See Set.Clear.
+func (m *Map[K, V]) Clear() {
+	m.tree.Clear()
+}
+
+// Return a new node holding item, with all links nil.
+func (root *Set[T]) newNode(item T) *node[T] {
+	if root.pool != nil {
+		return root.pool.get(item)
+	}
+	if root.augmenter != nil {
+		return root.augmenter.newNode(item)
+	}
+	return &node[T]{item: item}
+}
+
+// Return the node n, which is no longer in the tree, to the pool if
+// there is one.
+func (root *Set[T]) freeNode(n *node[T]) {
+	if root.pool == nil {
+		return
+	}
+	if root.augmenter != nil {
+		root.augmenter.clear(n)
+	}
+	root.pool.put(n)
+}
+
+// Return the nodes of the detached subtree n, which is no longer in the
+// tree, to the pool if there is one.
+func (root *Set[T]) freeSubtree(n *node[T]) {
+	if root.pool == nil {
+		return
+	}
+	for n != nil {
+		root.freeSubtree(n.left)
+		right := n.right
+		root.freeNode(n)
+		n = right
+	}
+}
+
+func (p *NodePool[T]) get(item T) *node[T] {
+	n := p.free
+	if n != nil {
+		p.free = n.right
+		p.numFree--
+		*n = node[T]{reuses: n.reuses + 1, aux: n.aux}
+	} else {
+		if len(p.slab) == 0 {
+			p.slab = make([]node[T], p.slabSize)
+		}
+		n = &p.slab[0]
+		p.slab = p.slab[1:]
+	}
+	n.item = item
+	return n
+}
+
+func (p *NodePool[T]) put(n *node[T]) {
+	// Drop references held by the node, so that the pool does not keep
+	// items or other nodes alive; the tree has already cleared the
+	// summary, which is kept for reuse. The tombstone lets
+	// WithIteratorChecks catch stale iterators until the node is reused,
+	// and the reuse count after that.
+	*n = node[T]{color: tombstone, right: p.free, reuses: n.reuses, aux: n.aux}
+	p.free = n
+	p.numFree++
+}
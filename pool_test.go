@@ -0,0 +1,123 @@
+package rbtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNodePoolReuse(t *testing.T) {
+	pool := NewNodePool[int](16)
+	s := NewSet(WithNodePool(pool))
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	testAssert(t, pool.numFree == 0, "no free nodes")
+	for i := 0; i < 100; i += 2 {
+		s.DeleteWithKey(i)
+	}
+	testAssert(t, pool.numFree == 50, "deleted nodes freed")
+	for i := 0; i < 100; i += 2 {
+		s.Insert(i)
+	}
+	testAssert(t, pool.numFree == 0, "free nodes reused")
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the pool is warm, churn allocates nothing.
+	allocs := testing.AllocsPerRun(100, func() {
+		s.DeleteWithKey(50)
+		s.Insert(50)
+	})
+	testAssert(t, allocs == 0, "allocations")
+
+	s.Clear()
+	testAssert(t, pool.numFree == 100 && s.Len() == 0 && s.Min().Limit(), "clear")
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodePoolBulkOperations(t *testing.T) {
+	pool := NewNodePool[int](0)
+	s := NewSet(WithNodePool(pool))
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		s.Insert(r.Intn(10000))
+	}
+	n := s.Len()
+	deleted := s.DeleteRange(1000, 3000)
+	deleted += s.DeleteIf(func(i int) bool { return i%3 == 0 })
+	testAssert(t, pool.numFree == deleted && s.Len() == n-deleted, "deleted nodes freed")
+
+	left, right := s.Split(5000)
+	testAssert(t, left.pool == pool && right.pool == pool, "split keeps pool")
+	right.DeleteWithKey(right.Min().Item())
+	testAssert(t, pool.numFree == deleted+1, "split tree frees to pool")
+	left.Clear()
+	right.Clear()
+	testAssert(t, pool.numFree == n, "all nodes freed")
+
+	// Failed decoding returns the nodes it allocated.
+	testAssert(t, s.UnmarshalBinary(nil) != nil, "bad input")
+	testAssert(t, s.UnmarshalJSON([]byte("[1,2,0]")) != nil, "out of order")
+	testAssert(t, pool.numFree == n, "nodes of failed build freed")
+}
+
+func TestNodePoolSetOperations(t *testing.T) {
+	pool := NewNodePool[int](0)
+	r := rand.New(rand.NewSource(1))
+	ops := map[string]func(a, b *Set[int]) *Set[int]{
+		"union":        func(a, b *Set[int]) *Set[int] { return Union(a, b, nil) },
+		"intersection": func(a, b *Set[int]) *Set[int] { return Intersection(a, b, nil) },
+		"difference":   Difference[int],
+		"symmetric":    SymmetricDifference[int],
+	}
+	for name, op := range ops {
+		for i := 0; i < 20; i++ {
+			a, b := NewSet(WithNodePool(pool)), NewSet(WithNodePool(pool))
+			for j := r.Intn(200); j > 0; j-- {
+				a.Insert(r.Intn(300))
+			}
+			for j := r.Intn(200); j > 0; j-- {
+				b.Insert(r.Intn(300))
+			}
+			used, free := a.Len()+b.Len(), pool.numFree
+			result := op(a, b)
+			testAssert(t, pool.numFree-free == used-result.Len(), name+": dropped nodes freed")
+			if err := result.Validate(); err != nil {
+				t.Fatal(name, err)
+			}
+			result.Clear()
+		}
+	}
+}
+
+func TestClearWithoutPool(t *testing.T) {
+	s := NewSet(WithIteratorChecks[int]())
+	s.Insert(1)
+	it := s.Min()
+	s.Clear()
+	testAssert(t, s.Len() == 0 && s.Max().NegativeLimit(), "clear")
+	testPanics(t, func() { it.Item() }, "stale iterator: the tree was split, joined, cleared or reloaded")
+	s.Insert(2)
+	testAssert(t, s.Len() == 1, "reuse after clear")
+}
+
+func TestMapNodePool(t *testing.T) {
+	pool := NewMapNodePool[int, string](0)
+	m := NewMap(WithMapNodePool(pool), WithMapIteratorChecks[int, string]())
+	mm := NewMultiMap(WithMapNodePool(pool))
+	for i := 0; i < 10; i++ {
+		m.Insert(i, "a")
+		mm.Insert(i%2, "b")
+	}
+	testAssert(t, mm.Count(0) == 5, "multimap keeps duplicates")
+	it := m.FindGE(3)
+	m.DeleteWithKey(3)
+	testAssert(t, pool.pool.numFree == 1, "deleted node freed")
+	testPanics(t, func() { it.Key() }, "deleted")
+	m.Clear()
+	mm.Clear()
+	testAssert(t, pool.pool.numFree == 20, "clear frees to the pool")
+}
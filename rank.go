@@ -0,0 +1,96 @@
+package rbtree
+
+// Order-statistic queries. Each node records the size of its subtree,
+// so these all run in O(log n).
+
+// Return the number of items < key. If key is in the tree, this is
+// its zero-based position in sort order.
+func (root *Set[T]) Rank(key T) int {
+	rank := 0
+	n := root.root
+	for n != nil {
+		if root.compare(key, n.item) <= 0 {
+			n = n.left
+		} else {
+			rank += getSize(n.left) + 1
+			n = n.right
+		}
+	}
+	return rank
+}
+
+// Create an iterator that points to the i'th smallest item in the
+// tree, counting from zero. If i < 0, return NegativeLimit(). If i >=
+// Len(), return Limit().
+func (root *Set[T]) Select(i int) SetIterator[T] {
+	if i < 0 {
+		return root.NegativeLimit()
+	}
+	n := root.root
+	for n != nil {
+		leftSize := getSize(n.left)
+		if i < leftSize {
+			n = n.left
+		} else if i == leftSize {
+			break
+		} else {
+			i -= leftSize + 1
+			n = n.right
+		}
+	}
+	return root.iterator(n)
+}
+
+// Return the number of items N such that lo <= N < hi.
+func (root *Set[T]) CountRange(lo, hi T) int {
+	if root.compare(lo, hi) >= 0 {
+		return 0
+	}
+	return root.Rank(hi) - root.Rank(lo)
+}
+
+// Return the zero-based position of the current element in sort
+// order. Return -1 if iter.NegativeLimit() and Len() if iter.Limit().
+//
+// REQUIRES: iter was created by a tree, not declared as a zero value.
+func (iter SetIterator[T]) Index() int {
+	if iter.root == nil {
+		panic("rbtree: Index called on a zero iterator")
+	}
+	if iter.Limit() {
+		return iter.root.Len()
+	}
+	if iter.NegativeLimit() {
+		return -1
+	}
+	n := iter.node
+	index := getSize(n.left)
+	for ; n.parent != nil; n = n.parent {
+		if n.isRightChild() {
+			index += getSize(n.parent.left) + 1
+		}
+	}
+	return index
+}
+
+// Return the number of keys < key.
+func (m *Map[K, V]) Rank(key K) int {
+	return m.tree.Rank(mapEntry[K, V]{key: key})
+}
+
+// Create an iterator that points to the element with the i'th
+// smallest key. See Set.Select.
+func (m *Map[K, V]) Select(i int) MapIterator[K, V] {
+	return MapIterator[K, V]{m.tree.Select(i)}
+}
+
+// Return the number of keys N such that lo <= N < hi.
+func (m *Map[K, V]) CountRange(lo, hi K) int {
+	return m.tree.CountRange(mapEntry[K, V]{key: lo}, mapEntry[K, V]{key: hi})
+}
+
+// Return the zero-based position of the current element in key
+// order. See SetIterator.Index.
+func (iter MapIterator[K, V]) Index() int {
+	return iter.iter.Index()
+}
@@ -0,0 +1,82 @@
+package rbtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Check that every node's size equals the size of its subtree.
+func checkSizes[T any](t *testing.T, n *node[T]) int {
+	if n == nil {
+		return 0
+	}
+	size := checkSizes(t, n.left) + checkSizes(t, n.right) + 1
+	if n.size != size {
+		t.Fatalf("node %v: size %d, want %d", n.item, n.size, size)
+	}
+	return size
+}
+
+func TestRankSelect(t *testing.T) {
+	tree := testNewIntSet()
+	testAssert(t, tree.Rank(10) == 0, "empty rank")
+	testAssert(t, tree.Select(0).Limit(), "empty select")
+	testAssert(t, tree.Select(-1).NegativeLimit(), "select -1")
+	testAssert(t, tree.Min().Index() == 0, "empty index")
+	for i := 0; i < 10; i = i + 2 {
+		tree.Insert(i)
+	}
+	testAssert(t, tree.Rank(-1) == 0, "rank -1")
+	testAssert(t, tree.Rank(0) == 0, "rank 0")
+	testAssert(t, tree.Rank(3) == 2, "rank 3")
+	testAssert(t, tree.Rank(4) == 2, "rank 4")
+	testAssert(t, tree.Rank(100) == 5, "rank 100")
+	testAssert(t, tree.Select(3).Item().(int) == 6, "select 3")
+	testAssert(t, tree.Select(5).Limit(), "select 5")
+	testAssert(t, tree.FindGE(5).Index() == 3, "index")
+	testAssert(t, tree.Limit().Index() == 5, "limit index")
+	testAssert(t, tree.NegativeLimit().Index() == -1, "neglimit index")
+	testAssert(t, tree.CountRange(2, 7) == 3, "count 2-7")
+	testAssert(t, tree.CountRange(3, 4) == 0, "count 3-4")
+	testAssert(t, tree.CountRange(7, 2) == 0, "count 7-2")
+}
+
+func TestRankRandomized(t *testing.T) {
+	const numKeys = 500
+	s := NewSet[int]()
+	keys := map[int]bool{}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(numKeys)
+		if r.Intn(3) == 0 {
+			testAssert(t, s.DeleteWithKey(key) == keys[key], "delete")
+			delete(keys, key)
+		} else {
+			testAssert(t, s.Insert(key) == !keys[key], "insert")
+			keys[key] = true
+		}
+		checkSizes(t, s.root)
+	}
+	sorted := []int{}
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Ints(sorted)
+	for i, k := range sorted {
+		testAssert(t, s.Rank(k) == i, "rank")
+		testAssert(t, s.Select(i).Item() == k, "select")
+		testAssert(t, s.FindGE(k).Index() == i, "index")
+	}
+}
+
+func TestMapRank(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Insert("a", 1)
+	m.Insert("c", 3)
+	m.Insert("e", 5)
+	testAssert(t, m.Rank("d") == 2, "rank")
+	testAssert(t, m.Select(1).Value() == 3, "select")
+	testAssert(t, m.FindGE("e").Index() == 2, "index")
+	testAssert(t, m.CountRange("b", "z") == 2, "count")
+}
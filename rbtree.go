@@ -10,12 +10,11 @@
 package rbtree
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
 )
 
-var verb bool
-
 //
 // Public definitions
 //
@@ -26,92 +25,153 @@ type Item interface{}
 // CompareFunc returns 0 if a==b, <0 if a<b, >0 if a>b.
 type CompareFunc func(a, b Item) int
 
-type Tree struct {
+// Tree is a set of untyped Items. New code should prefer Set or Map,
+// which avoid boxing and type assertions.
+type Tree = Set[Item]
+
+// Iterator is an iterator over a Tree.
+type Iterator = SetIterator[Item]
+
+// Set is a red-black tree that stores values of type T in the order
+// defined by its comparison function.
+type Set[T any] struct {
 	// Root of the tree
-	root *node
+	root *node[T]
 
 	// The minimum and maximum nodes under the root.
-	minNode, maxNode *node
+	minNode, maxNode *node[T]
+
+	// Sentinel that NegativeLimit iterators point to.
+	negativeLimitNode node[T]
 
 	// Number of nodes under root, including the root
 	count   int
-	compare CompareFunc
+	compare func(a, b T) int
+
+	// If non-nil, allocates nodes with room for a summary and maintains
+	// the summaries.
+	augmenter augmenterHook[T]
+
+	// If true, the tree may hold several items that compare equal.
+	multi bool
+
+	// If non-nil, encodes and decodes items when the tree is marshalled.
+	codec Codec[T]
+
+	// If non-nil, nodes are allocated from and returned to this pool.
+	pool *NodePool[T]
+
+	// If true, iterators check that they are still valid before use.
+	checkIterators bool
+
+	// Incremented by operations that move nodes out of the tree without
+	// tombstoning them, which invalidates every iterator.
+	generation uint64
 }
 
+// Option configures a tree when it is created.
+type Option[T any] func(*Set[T])
+
 // Create a new empty tree.
-func NewTree(compare CompareFunc) *Tree {
-	return &Tree{compare: compare}
+func NewTree(compare CompareFunc, opts ...Option[Item]) *Tree {
+	return NewSetFunc[Item](compare, opts...)
+}
+
+// Create a new empty set. compare returns 0 if a==b, <0 if a<b, >0 if
+// a>b.
+func NewSetFunc[T any](compare func(a, b T) int, opts ...Option[T]) *Set[T] {
+	root := &Set[T]{compare: compare}
+	for _, opt := range opts {
+		opt(root)
+	}
+	return root
+}
+
+// Create a new empty set ordered by the natural order of T.
+func NewSet[T cmp.Ordered](opts ...Option[T]) *Set[T] {
+	return NewSetFunc(cmp.Compare[T], opts...)
 }
 
 // Return the number of elements in the tree.
-func (root *Tree) Len() int {
+func (root *Set[T]) Len() int {
 	return root.count
 }
 
 // A convenience function for finding an element equal to key. Return
-// nil if not found.
-func (root *Tree) Get(key Item) Item {
+// nil (the zero value of T) if not found.
+func (root *Set[T]) Get(key T) T {
+	item, _ := root.Lookup(key)
+	return item
+}
+
+// Find an element equal to key. The 2nd return value is true iff
+// such an element exists.
+func (root *Set[T]) Lookup(key T) (T, bool) {
 	n, exact := root.findGE(key)
 	if exact {
-		return n.item
+		return n.item, true
 	}
-	return nil
+	var zero T
+	return zero, false
 }
 
 // Create an iterator that points to the minimum item in the tree
 // If the tree is empty, return Limit()
-func (root *Tree) Min() Iterator {
-	return Iterator{root, root.minNode}
+func (root *Set[T]) Min() SetIterator[T] {
+	return root.iterator(root.minNode)
 }
 
 // Create an iterator that points at the maximum item in the tree
 //
 // If the tree is empty, return NegativeLimit()
-func (root *Tree) Max() Iterator {
+func (root *Set[T]) Max() SetIterator[T] {
 	if root.maxNode == nil {
 		// TODO: there are a few checks of this form.
 		// Perhaps set maxNode=negativeLimit when the tree is empty
-		return Iterator{root, negativeLimitNode}
+		return root.NegativeLimit()
 	}
-	return Iterator{root, root.maxNode}
+	return root.iterator(root.maxNode)
 }
 
 // Create an iterator that points beyond the maximum item in the tree
-func (root *Tree) Limit() Iterator {
-	return Iterator{root, nil}
+func (root *Set[T]) Limit() SetIterator[T] {
+	return root.iterator(nil)
 }
 
 // Create an iterator that points before the minimum item in the tree
-func (root *Tree) NegativeLimit() Iterator {
-	return Iterator{root, negativeLimitNode}
+func (root *Set[T]) NegativeLimit() SetIterator[T] {
+	return root.iterator(&root.negativeLimitNode)
 }
 
 // Find the smallest element N such that N >= key, and return the
 // iterator pointing to the element. If no such element is found,
 // return root.Limit().
-func (root *Tree) FindGE(key Item) Iterator {
+func (root *Set[T]) FindGE(key T) SetIterator[T] {
 	n, _ := root.findGE(key)
-	return Iterator{root, n}
+	return root.iterator(n)
 }
 
 // Find the largest element N such that N <= key, and return the
 // iterator pointing to the element. If no such element is found,
 // return iter.NegativeLimit().
-func (root *Tree) FindLE(key Item) Iterator {
-	n, exact := root.findGE(key)
-	if exact {
-		return Iterator{root, n}
-	}
-	if n != nil {
-		return Iterator{root, n.doPrev()}
+func (root *Set[T]) FindLE(key T) SetIterator[T] {
+	n := root.findLE(key)
+	if n == nil {
+		return root.NegativeLimit()
 	}
-	if root.maxNode == nil {
-		return Iterator{root, negativeLimitNode}
+	return root.iterator(n)
+}
+
+// Return an iterator that points to the predecessor of n, or
+// NegativeLimit() if n is the minimum node.
+func (root *Set[T]) prevIterator(n *node[T]) SetIterator[T] {
+	if p := n.doPrev(); p != nil {
+		return root.iterator(p)
 	}
-	return Iterator{root, root.maxNode}
+	return root.NegativeLimit()
 }
 
-func getGU(n *node) (grandparent, uncle *node) {
+func getGU[T any](n *node[T]) (grandparent, uncle *node[T]) {
 	grandparent = n.parent.parent
 	if n.parent.isLeftChild() {
 		uncle = grandparent.right
@@ -123,28 +183,37 @@ func getGU(n *node) (grandparent, uncle *node) {
 
 // Insert an item. If the item is already in the tree, do nothing and
 // return false. Else return true.
-func (root *Tree) Insert(item Item) bool {
+//
+// If the tree allows duplicates, the item is always inserted, after any
+// equal items already in the tree.
+func (root *Set[T]) Insert(item T) bool {
 
 	// TODO: delay creating n until it is found to be inserted
 	n := root.doInsert(item)
 	if n == nil {
 		return false
 	}
+	root.insertFixup(n)
+	return true
+}
 
+// Restore the red-black properties after n was linked in as a new
+// leaf. Return true iff this added one to the black height of the tree.
+func (root *Set[T]) insertFixup(n *node[T]) bool {
 	n.color = red
-	var uncle, grandparent *node
+	var uncle, grandparent *node[T]
 	for {
 
 		// Case 1: N is at the root
 		if n.parent == nil {
 			n.color = black
-			break
+			return true
 		}
 
 		// Case 2: The parent is black, so the tree already
 		// satisfies the RB properties
 		if n.parent.color == black {
-			break
+			return false
 		}
 
 		// Case 3: parent and uncle are both red.
@@ -188,14 +257,13 @@ func (root *Tree) Insert(item Item) bool {
 				panic(fmt.Sprintf("assertion fails: should not get here on case 5."))
 			}
 		}
-		break
+		return false
 	}
-	return true
 }
 
 // Delete an item with the given key. Return true iff the item was
-// found.
-func (root *Tree) DeleteWithKey(key Item) bool {
+// found. If there are several such items, delete the first one.
+func (root *Set[T]) DeleteWithKey(key T) bool {
 	n, exact := root.findGE(key)
 	if exact {
 		root.doDelete(n)
@@ -204,88 +272,157 @@ func (root *Tree) DeleteWithKey(key Item) bool {
 	return false
 }
 
-// Delete the current item.
+// Delete the current item, and return an iterator to its successor (or
+// Limit()), like C++ std::map::erase. This makes it possible to delete
+// items while scanning:
+//
+//	for it := tree.Min(); !it.Limit(); {
+//		if shouldDelete(it.Item()) {
+//			it = tree.DeleteWithIterator(it)
+//		} else {
+//			it = it.Next()
+//		}
+//	}
+//
+// Iterators to other items remain valid. Panic with the error
+// TryDeleteWithIterator would return if iter cannot be deleted.
 //
 // REQUIRES: !iter.Limit() && !iter.NegativeLimit()
-func (root *Tree) DeleteWithIterator(iter Iterator) {
+func (root *Set[T]) DeleteWithIterator(iter SetIterator[T]) SetIterator[T] {
+	next, err := root.TryDeleteWithIterator(iter)
+	if err != nil {
+		panic(err)
+	}
+	return next
+}
+
+// Like DeleteWithIterator, but return ErrForeignIterator if iter is
+// from another tree, ErrIteratorAtLimit if it is at a limit, or
+// ErrStaleIterator if it has been invalidated (detected only with
+// WithIteratorChecks), instead of panicking. The tree is unchanged if
+// an error is returned.
+func (root *Set[T]) TryDeleteWithIterator(iter SetIterator[T]) (SetIterator[T], error) {
 	if iter.root != root {
-		panic("DeleteWithIterator called with iterator not from this tree.")
+		return iter, ErrForeignIterator
 	}
-	doAssert(!iter.Limit() && !iter.NegativeLimit())
+	if iter.Limit() || iter.NegativeLimit() {
+		return iter, ErrIteratorAtLimit
+	}
+	if err := iter.validate(); err != nil {
+		return iter, err
+	}
+	// doDelete relinks nodes rather than moving items between them, so
+	// the successor node stays in the tree.
+	next := iter.node.doNext()
 	root.doDelete(iter.node)
+	return root.iterator(next), nil
 }
 
-// Iterator allows scanning tree elements in sort order.
+// SetIterator allows scanning tree elements in sort order.
 //
 // Iterator invalidation rule is the same as C++ std::map<>'s. That
 // is, if you delete the element that an iterator points to, the
 // iterator becomes invalid. For other operation types, the iterator
-// remains valid.
-type Iterator struct {
-	root *Tree
-	node *node
+// remains valid. Trees created with WithIteratorChecks panic when an
+// invalid iterator is used.
+type SetIterator[T any] struct {
+	root *Set[T]
+	node *node[T]
+	// root.generation and node.reuses when the iterator was created.
+	gen    uint64
+	reuses uint32
 }
 
 // allow clients to verify iterator is from the right tree.
-func (iter Iterator) Tree() *Tree {
+func (iter SetIterator[T]) Tree() *Set[T] {
 	return iter.root
 }
 
-func (iter Iterator) Equal(iter2 Iterator) bool {
+func (iter SetIterator[T]) Equal(iter2 SetIterator[T]) bool {
 	return iter.node == iter2.node
 }
 
 // Check if the iterator points beyond the max element in the tree
-func (iter Iterator) Limit() bool {
+func (iter SetIterator[T]) Limit() bool {
 	return iter.node == nil
 }
 
 // Check if the iterator points to the minimum element in the tree
-func (iter Iterator) Min() bool {
+func (iter SetIterator[T]) Min() bool {
 	return iter.node == iter.root.minNode
 }
 
 // Check if the iterator points to the maximum element in the tree
-func (iter Iterator) Max() bool {
+func (iter SetIterator[T]) Max() bool {
 	return iter.node == iter.root.maxNode
 }
 
 // Check if the iterator points before the minumum element in the tree
-func (iter Iterator) NegativeLimit() bool {
-	return iter.node == negativeLimitNode
+func (iter SetIterator[T]) NegativeLimit() bool {
+	return iter.root != nil && iter.node == &iter.root.negativeLimitNode
 }
 
 // Return the current element.
 //
 // REQUIRES: !iter.Limit() && !iter.NegativeLimit()
-func (iter Iterator) Item() interface{} {
+func (iter SetIterator[T]) Item() T {
+	iter.checkValid()
 	return iter.node.item
 }
 
 // Create a new iterator that points to the successor of the current element.
 //
 // REQUIRES: !iter.Limit()
-func (iter Iterator) Next() Iterator {
-	doAssert(!iter.Limit())
+func (iter SetIterator[T]) Next() SetIterator[T] {
+	next, err := iter.TryNext()
+	if err != nil {
+		panic(err)
+	}
+	return next
+}
+
+// Like Next, but return ErrIteratorAtLimit if the iterator is at
+// Limit(), or ErrStaleIterator if it has been invalidated (detected only
+// with WithIteratorChecks), instead of panicking.
+func (iter SetIterator[T]) TryNext() (SetIterator[T], error) {
+	if iter.Limit() {
+		return iter, ErrIteratorAtLimit
+	}
+	if err := iter.validate(); err != nil {
+		return iter, err
+	}
 	if iter.NegativeLimit() {
-		return Iterator{iter.root, iter.root.minNode}
+		return iter.root.iterator(iter.root.minNode), nil
 	}
-	return Iterator{iter.root, iter.node.doNext()}
+	return iter.root.iterator(iter.node.doNext()), nil
 }
 
 // Create a new iterator that points to the predecessor of the current
 // node.
 //
 // REQUIRES: !iter.NegativeLimit()
-func (iter Iterator) Prev() Iterator {
-	doAssert(!iter.NegativeLimit())
-	if !iter.Limit() {
-		return Iterator{iter.root, iter.node.doPrev()}
+func (iter SetIterator[T]) Prev() SetIterator[T] {
+	prev, err := iter.TryPrev()
+	if err != nil {
+		panic(err)
 	}
-	if iter.root.maxNode == nil {
-		return Iterator{iter.root, negativeLimitNode}
+	return prev
+}
+
+// Like Prev, but return ErrIteratorAtLimit if the iterator is at
+// NegativeLimit(), or ErrStaleIterator if it has been invalidated
+// (detected only with WithIteratorChecks), instead of panicking.
+func (iter SetIterator[T]) TryPrev() (SetIterator[T], error) {
+	if iter.NegativeLimit() {
+		return iter, ErrIteratorAtLimit
+	}
+	if err := iter.validate(); err != nil {
+		return iter, err
 	}
-	return Iterator{iter.root, iter.root.maxNode}
+	if !iter.Limit() {
+		return iter.root.prevIterator(iter.node), nil
+	}
+	return iter.root.Max(), nil
 }
 
 func doAssert(b bool) {
@@ -297,34 +434,50 @@ func doAssert(b bool) {
 const red = iota
 const black = 1 + iota
 
-type node struct {
-	myTree              *Tree
-	item                Item
-	parent, left, right *node
-	color               int // black or red
-}
+type node[T any] struct {
+	item                T
+	parent, left, right *node[T]
+	color               int32 // black or red
+
+	// Number of times a NodePool has handed out this node for a new
+	// item, so that checked iterators can tell that it was reused.
+	reuses uint32
 
-var negativeLimitNode *node
+	// Number of nodes in the subtree rooted at this node, including
+	// itself.
+	size int
+
+	// Owned by the tree's augmenter, which keeps the summary of the
+	// subtree rooted at this node here.
+	aux any
+}
 
 //
 // Internal node attribute accessors
 //
-func getColor(n *node) int {
+func getColor[T any](n *node[T]) int32 {
 	if n == nil {
 		return black
 	}
 	return n.color
 }
 
-func (n *node) isLeftChild() bool {
+func getSize[T any](n *node[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *node[T]) isLeftChild() bool {
 	return n == n.parent.left
 }
 
-func (n *node) isRightChild() bool {
+func (n *node[T]) isRightChild() bool {
 	return n == n.parent.right
 }
 
-func (n *node) sibling() *node {
+func (n *node[T]) sibling() *node[T] {
 	doAssert(n.parent != nil)
 	if n.isLeftChild() {
 		return n.parent.right
@@ -334,7 +487,7 @@ func (n *node) sibling() *node {
 
 // Return the minimum node that's larger than N. Return nil if no such
 // node is found.
-func (n *node) doNext() *node {
+func (n *node[T]) doNext() *node[T] {
 	if n.right != nil {
 		m := n.right
 		for m.left != nil {
@@ -358,7 +511,7 @@ func (n *node) doNext() *node {
 
 // Return the maximum node that's smaller than N. Return nil if no
 // such node is found.
-func (n *node) doPrev() *node {
+func (n *node[T]) doPrev() *node[T] {
 	if n.left != nil {
 		return maxPredecessor(n)
 	}
@@ -373,11 +526,11 @@ func (n *node) doPrev() *node {
 		}
 		n = p
 	}
-	return negativeLimitNode
+	return nil
 }
 
 // Return the predecessor of "n".
-func maxPredecessor(n *node) *node {
+func maxPredecessor[T any](n *node[T]) *node[T] {
 	doAssert(n.left != nil)
 	m := n.left
 	for m.right != nil {
@@ -394,7 +547,7 @@ func maxPredecessor(n *node) *node {
 // Private methods
 //
 
-func (root *Tree) recomputeMinNode() {
+func (root *Set[T]) recomputeMinNode() {
 	root.minNode = root.root
 	if root.minNode != nil {
 		for root.minNode.left != nil {
@@ -403,7 +556,7 @@ func (root *Tree) recomputeMinNode() {
 	}
 }
 
-func (root *Tree) recomputeMaxNode() {
+func (root *Set[T]) recomputeMaxNode() {
 	root.maxNode = root.root
 	if root.maxNode != nil {
 		for root.maxNode.right != nil {
@@ -412,7 +565,7 @@ func (root *Tree) recomputeMaxNode() {
 	}
 }
 
-func (root *Tree) maybeSetMinNode(n *node) {
+func (root *Set[T]) maybeSetMinNode(n *node[T]) {
 	if root.minNode == nil {
 		root.minNode = n
 		root.maxNode = n
@@ -421,48 +574,35 @@ func (root *Tree) maybeSetMinNode(n *node) {
 	}
 }
 
-func (root *Tree) maybeSetMaxNode(n *node) {
+func (root *Set[T]) maybeSetMaxNode(n *node[T]) {
 	if root.maxNode == nil {
 		root.minNode = n
 		root.maxNode = n
-	} else if root.compare(n.item, root.maxNode.item) > 0 {
+	} else if root.compare(n.item, root.maxNode.item) >= 0 {
 		root.maxNode = n
 	}
 }
 
 // Try inserting "item" into the tree. Return nil if the item is
 // already in the tree. Otherwise return a new (leaf) node.
-func (root *Tree) doInsert(item Item) *node {
+func (root *Set[T]) doInsert(item T) *node[T] {
 	if root.root == nil {
-		n := &node{item: item, myTree: root}
-		root.root = n
-		root.minNode = n
-		root.maxNode = n
-		root.count++
-		return n
+		return root.link(item, nil, 0)
 	}
 	parent := root.root
 	for true {
 		comp := root.compare(item, parent.item)
-		if comp == 0 {
+		if comp == 0 && !root.multi {
 			return nil
 		} else if comp < 0 {
 			if parent.left == nil {
-				n := &node{item: item, parent: parent, myTree: root}
-				parent.left = n
-				root.count++
-				root.maybeSetMinNode(n)
-				return n
+				return root.link(item, parent, comp)
 			} else {
 				parent = parent.left
 			}
 		} else {
 			if parent.right == nil {
-				n := &node{item: item, parent: parent, myTree: root}
-				parent.right = n
-				root.count++
-				root.maybeSetMaxNode(n)
-				return n
+				return root.link(item, parent, comp)
 			} else {
 				parent = parent.right
 			}
@@ -471,46 +611,113 @@ func (root *Tree) doInsert(item Item) *node {
 	panic("should not reach here")
 }
 
-// Find a node whose item >= key. The 2nd return value is true iff the
-// node.item==key. Returns (nil, false) if all nodes in the tree are <
-// key.
-func (root *Tree) findGE(key Item) (*node, bool) {
+// Create a new leaf node for item and make it the left (if comp < 0)
+// or right child of parent. If parent is nil, the tree must be empty.
+// The caller must call insertFixup on the result.
+func (root *Set[T]) link(item T, parent *node[T], comp int) *node[T] {
+	if parent == nil {
+		n := root.newNode(item)
+		root.update(n)
+		root.root = n
+		root.minNode = n
+		root.maxNode = n
+		root.count++
+		return n
+	}
+	n := root.newNode(item)
+	n.parent = parent
+	if comp < 0 {
+		parent.left = n
+	} else {
+		parent.right = n
+	}
+	root.updatePath(n)
+	root.count++
+	if comp < 0 {
+		root.maybeSetMinNode(n)
+	} else {
+		root.maybeSetMaxNode(n)
+	}
+	return n
+}
+
+// Find the first node whose item equals key. If there is none, return
+// nil along with the node under which key would be linked and the
+// result of comparing key with that node (see link).
+func (root *Set[T]) locate(key T) (found, parent *node[T], comp int) {
 	n := root.root
-	for true {
-		if n == nil {
-			return nil, false
-		}
-		comp := root.compare(key, n.item)
+	for n != nil {
+		parent = n
+		comp = root.compare(key, n.item)
 		if comp == 0 {
-			return n, true
-		} else if comp < 0 {
-			if n.left != nil {
-				n = n.left
-			} else {
-				return n, false
+			found = n
+			if !root.multi {
+				break
 			}
+			comp = -1
+		}
+		if comp < 0 {
+			n = n.left
 		} else {
-			if n.right != nil {
-				n = n.right
-			} else {
-				succ := n.doNext()
-				if succ == nil {
-					return nil, false
-				} else {
-					comp = root.compare(key, succ.item)
-					return succ, (comp == 0)
-				}
+			n = n.right
+		}
+	}
+	if found != nil {
+		return found, nil, 0
+	}
+	return nil, parent, comp
+}
+
+// Find the first node whose item >= key. The 2nd return value is
+// true iff the node.item==key. Returns (nil, false) if all nodes in the
+// tree are < key.
+func (root *Set[T]) findGE(key T) (*node[T], bool) {
+	var ge *node[T]
+	exact := false
+	n := root.root
+	for n != nil {
+		comp := root.compare(key, n.item)
+		if comp <= 0 {
+			ge, exact = n, comp == 0
+			if exact && !root.multi {
+				break
 			}
+			// Keep going left to find the first of the equal items.
+			n = n.left
+		} else {
+			n = n.right
 		}
 	}
-	panic("should not reach here")
+	return ge, exact
 }
 
-// Delete N from the tree.
-func (root *Tree) doDelete(n *node) {
-	if n.myTree != nil && n.myTree != root {
-		panic(fmt.Sprintf("delete applied to node that was not from our tree... n has tree: '%s'\n\n while root has tree: '%s'\n\n", n.myTree.DumpAsString(), root.DumpAsString()))
+// Find the first node whose item > key. Returns nil if all nodes in
+// the tree are <= key.
+func (root *Set[T]) findGT(key T) *node[T] {
+	var gt *node[T]
+	n := root.root
+	for n != nil {
+		if root.compare(key, n.item) < 0 {
+			gt = n
+			n = n.left
+		} else {
+			n = n.right
+		}
 	}
+	return gt
+}
+
+// Find the last node whose item <= key. Returns nil if all nodes in
+// the tree are > key.
+func (root *Set[T]) findLE(key T) *node[T] {
+	if n := root.findGT(key); n != nil {
+		return n.doPrev()
+	}
+	return root.maxNode
+}
+
+// Delete N from the tree.
+func (root *Set[T]) doDelete(n *node[T]) {
 	if n.left != nil && n.right != nil {
 		pred := maxPredecessor(n)
 		root.swapNodes(n, pred)
@@ -526,9 +733,11 @@ func (root *Tree) doDelete(n *node) {
 		root.deleteCase1(n)
 	}
 	root.replaceNode(n, child)
+	root.updatePath(n.parent)
 	if n.parent == nil && child != nil {
 		child.color = black
 	}
+	n.color = tombstone
 	root.count--
 	if root.count == 0 {
 		root.minNode = nil
@@ -541,17 +750,20 @@ func (root *Tree) doDelete(n *node) {
 			root.recomputeMaxNode()
 		}
 	}
+	root.freeNode(n)
 }
 
 // Move n to the pred's place, and vice versa
 //
 // TODO: this code is overly convoluted
-func (root *Tree) swapNodes(n, pred *node) {
+func (root *Set[T]) swapNodes(n, pred *node[T]) {
 	doAssert(pred != n)
 	isLeft := pred.isLeftChild()
 	tmp := *pred
 	root.replaceNode(n, pred)
 	pred.color = n.color
+	pred.size = n.size
+	pred.aux = n.aux
 
 	if tmp.parent == n {
 		// swap the positions of n and pred
@@ -605,9 +817,11 @@ func (root *Tree) swapNodes(n, pred *node) {
 		}
 	}
 	n.color = tmp.color
+	n.size = tmp.size
+	n.aux = tmp.aux
 }
 
-func (root *Tree) deleteCase1(n *node) {
+func (root *Set[T]) deleteCase1(n *node[T]) {
 	for true {
 		if n.parent != nil {
 			if getColor(n.sibling()) == red {
@@ -643,7 +857,7 @@ func (root *Tree) deleteCase1(n *node) {
 	}
 }
 
-func (root *Tree) deleteCase5(n *node) {
+func (root *Set[T]) deleteCase5(n *node[T]) {
 	if n == n.parent.left &&
 		getColor(n.sibling()) == black &&
 		getColor(n.sibling().left) == red &&
@@ -674,7 +888,22 @@ func (root *Tree) deleteCase5(n *node) {
 	}
 }
 
-func (root *Tree) replaceNode(oldn, newn *node) {
+// Recompute the size and summary of n from its children.
+func (root *Set[T]) update(n *node[T]) {
+	n.size = getSize(n.left) + getSize(n.right) + 1
+	if root.augmenter != nil {
+		root.augmenter.update(n)
+	}
+}
+
+// Recompute n and all of its ancestors, bottom up.
+func (root *Set[T]) updatePath(n *node[T]) {
+	for ; n != nil; n = n.parent {
+		root.update(n)
+	}
+}
+
+func (root *Set[T]) replaceNode(oldn, newn *node[T]) {
 	if oldn.parent == nil {
 		root.root = newn
 	} else {
@@ -694,7 +923,7 @@ func (root *Tree) replaceNode(oldn, newn *node) {
   A   Y	    => X   C
      B C 	  A B
 */
-func (root *Tree) rotateLeft(n *node) {
+func (root *Set[T]) rotateLeft(n *node[T]) {
 	r := n.right
 	root.replaceNode(n, r)
 	n.right = r.left
@@ -703,6 +932,8 @@ func (root *Tree) rotateLeft(n *node) {
 	}
 	r.left = n
 	n.parent = r
+	root.update(n)
+	root.update(r)
 
 	/*
 		y := x.right
@@ -734,7 +965,7 @@ func (root *Tree) rotateLeft(n *node) {
    X   C  =>   A   Y
   A B             B C
 */
-func (root *Tree) rotateRight(n *node) {
+func (root *Set[T]) rotateRight(n *node[T]) {
 	L := n.left
 	root.replaceNode(n, L)
 	n.left = L.right
@@ -743,26 +974,22 @@ func (root *Tree) rotateRight(n *node) {
 	}
 	L.right = n
 	n.parent = L
+	root.update(n)
+	root.update(L)
 }
 
-func init() {
-	negativeLimitNode = &node{}
-}
-
-func (root *Tree) DumpAsString() string {
+func (root *Set[T]) DumpAsString() string {
 	s := ""
 	i := 0
-	verb = true
-	for it := root.Min(); it != root.Limit(); it = it.Next() {
-		s += fmt.Sprintf("node %03d: %#v\n", i, it.Item())
+	for item := range root.All() {
+		s += fmt.Sprintf("node %03d: %#v\n", i, item)
 		i++
 	}
 	return s
 }
 
-func (root *Tree) Dump() {
+func (root *Set[T]) Dump() {
 	i := 0
-	verb = true
 	for it := root.Min(); it != root.Limit(); it = it.Next() {
 		fmt.Printf("node %03d: %#v\n", i, it.Item())
 		i++
@@ -775,14 +1002,14 @@ func (root *Tree) Dump() {
 	root.Walk(n, 0, "root")
 }
 
-func colorString(n *node) string {
+func colorString[T any](n *node[T]) string {
 	if n.color == red {
 		return "red"
 	}
 	return "black"
 }
 
-func (tr *Tree) Walk(n *node, indent int, lab string) {
+func (tr *Set[T]) Walk(n *node[T], indent int, lab string) {
 
 	spc := strings.Repeat(" ", indent*3)
 	var parItem, leftItem, rightItem interface{}
@@ -818,7 +1045,7 @@ func (tr *Tree) Walk(n *node, indent int, lab string) {
 
 var validations int
 
-func validateTree2(tr *Tree) {
+func validateTree2[T any](tr *Set[T]) {
 	if tr == nil {
 		panic("can't validate a nil tree")
 	}
@@ -835,7 +1062,7 @@ func validateTree2(tr *Tree) {
 	validations++
 }
 
-func (tr *Tree) validateTreeHelper(n *node) {
+func (tr *Set[T]) validateTreeHelper(n *node[T]) {
 
 	if n.parent != nil {
 		if n.parent.left != n && n.parent.right != n {
@@ -78,6 +78,49 @@ func TestDelete(t *testing.T) {
 
 }
 
+func TestDeleteWhileIterating(t *testing.T) {
+	tree := testNewIntSet()
+	for i := 0; i < 10; i++ {
+		tree.Insert(i)
+	}
+	for it := tree.Min(); !it.Limit(); {
+		if it.Item().(int)%2 == 0 {
+			it = tree.DeleteWithIterator(it)
+		} else {
+			it = it.Next()
+		}
+	}
+	testAssert(t, iterToString(tree.Min()) == "1,3,5,7,9", "odd items remain")
+	testAssert(t, tree.DeleteWithIterator(tree.Max()).Limit(), "successor of max")
+}
+
+// Deleting a node with two children first swaps it with its
+// predecessor. Check that iterators to every other item survive that.
+func TestDeleteKeepsOtherIterators(t *testing.T) {
+	const n = 200
+	tree := NewSet(WithIteratorChecks[int]())
+	iters := map[int]SetIterator[int]{}
+	for i := 0; i < n; i++ {
+		it, _ := tree.InsertOrGet(i)
+		iters[i] = it
+	}
+	r := rand.New(rand.NewSource(0))
+	for _, k := range r.Perm(n) {
+		next := tree.DeleteWithIterator(iters[k])
+		delete(iters, k)
+		want := tree.FindGE(k)
+		testAssert(t, next.Equal(want), "returned successor")
+		for key, it := range iters {
+			testAssert(t, it.Item() == key, "item moved")
+			if key+1 < n {
+				if _, ok := iters[key+1]; ok {
+					testAssert(t, it.Next().Item() == key+1, "next")
+				}
+			}
+		}
+	}
+}
+
 func iterToString(i Iterator) string {
 	s := ""
 	for ; !i.Limit(); i = i.Next() {
@@ -128,6 +171,13 @@ func TestIterator(t *testing.T) {
 	}
 }
 
+func TestZeroIterator(t *testing.T) {
+	var iter Iterator
+	testAssert(t, iter.Limit(), "zero iterator is at the limit")
+	testAssert(t, !iter.NegativeLimit(), "zero iterator is not at the negative limit")
+	testPanics(t, func() { iter.Index() }, "rbtree: Index called on a zero iterator")
+}
+
 //
 // Randomized tests
 //
@@ -348,7 +398,7 @@ func TestRandomized(t *testing.T) {
 // Examples
 //
 
-func ExampleIntString() {
+func Example_intString() {
 	type MyItem struct {
 		key   int
 		value string
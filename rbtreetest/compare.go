@@ -0,0 +1,93 @@
+package rbtreetest
+
+import "fmt"
+
+// ComparatorError reports a property that a comparison function
+// violates, and the items that show it.
+type ComparatorError struct {
+	// One of "consistency", "reflexivity", "antisymmetry" and
+	// "transitivity".
+	Property string
+	Items    []interface{}
+	Detail   string
+}
+
+func (e *ComparatorError) Error() string {
+	return fmt.Sprintf("rbtreetest: comparator violates %s for %v: %s", e.Property, e.Items, e.Detail)
+}
+
+// Check that compare defines a total preorder over items, which is
+// what rbtree needs for its ordering to be meaningful:
+//
+//   - consistency: compare(a, b) returns the same sign every time;
+//   - reflexivity: compare(a, a) == 0;
+//   - antisymmetry: compare(a, b) and compare(b, a) have opposite signs;
+//   - transitivity: a <= b and b <= c imply a <= c, and likewise for <
+//     and ==.
+//
+// Return nil if all the properties hold, or else a *ComparatorError for
+// the first violation found. This calls compare O(n^3) times, so items
+// should be a small but varied sample, including equal items if the
+// comparator can return 0 for distinct values.
+func CheckComparator[T any](compare func(a, b T) int, items []T) error {
+	n := len(items)
+	// sign[i][j] is the sign of compare(items[i], items[j]).
+	sign := make([][]int, n)
+	for i, a := range items {
+		sign[i] = make([]int, n)
+		for j, b := range items {
+			s := signOf(compare(a, b))
+			if again := signOf(compare(a, b)); again != s {
+				return &ComparatorError{"consistency", []interface{}{a, b},
+					fmt.Sprintf("compare returned %d, then %d", s, again)}
+			}
+			sign[i][j] = s
+		}
+		if sign[i][i] != 0 {
+			return &ComparatorError{"reflexivity", []interface{}{a},
+				fmt.Sprintf("compare(a, a) = %d", sign[i][i])}
+		}
+	}
+	for i := range items {
+		for j := range items {
+			if sign[i][j] != -sign[j][i] {
+				return &ComparatorError{"antisymmetry", []interface{}{items[i], items[j]},
+					fmt.Sprintf("compare(a, b) = %d, compare(b, a) = %d", sign[i][j], sign[j][i])}
+			}
+		}
+	}
+	for i := range items {
+		for j := range items {
+			if sign[i][j] > 0 {
+				continue
+			}
+			for k := range items {
+				if sign[j][k] > 0 {
+					continue
+				}
+				// a <= b <= c, so a <= c, and a == c only if
+				// a == b == c.
+				want := sign[i][j]
+				if want == 0 {
+					want = sign[j][k]
+				}
+				if sign[i][k] != want {
+					return &ComparatorError{"transitivity", []interface{}{items[i], items[j], items[k]},
+						fmt.Sprintf("compare(a, b) = %d and compare(b, c) = %d, but compare(a, c) = %d",
+							sign[i][j], sign[j][k], sign[i][k])}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func signOf(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
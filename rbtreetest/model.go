@@ -0,0 +1,191 @@
+// Package rbtreetest provides tools for testing code that stores its own
+// item types in rbtree sets: a checker for comparison functions, and a
+// simple reference model that a Set can be checked against after
+// arbitrary operations.
+package rbtreetest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/yasushi-saito/rbtree"
+)
+
+// Model is a reference implementation of the core operations of
+// rbtree.Set, kept as a sorted slice. It is slow but simple enough to be
+// obviously correct.
+type Model[T any] struct {
+	compare    func(a, b T) int
+	duplicates bool
+	items      []T
+	// Used to compare the set's items with the model's. If nil, they
+	// are compared with compare.
+	equal func(a, b T) bool
+}
+
+// Create an empty model ordered by compare. duplicates must match
+// whether the set being checked was created with rbtree.WithDuplicates.
+func NewModel[T any](compare func(a, b T) int, duplicates bool) *Model[T] {
+	return &Model[T]{compare: compare, duplicates: duplicates}
+}
+
+// Make Check and Exercise compare the set's items with the model's using
+// equal instead of the comparison function, and return m. Comparing only
+// keys cannot catch a set that holds the wrong item for a key, such as a
+// stale value, or equal items in the wrong order.
+func (m *Model[T]) WithEqual(equal func(a, b T) bool) *Model[T] {
+	m.equal = equal
+	return m
+}
+
+// Check if a set item matches a model item.
+func (m *Model[T]) same(a, b T) bool {
+	if m.equal != nil {
+		return m.equal(a, b)
+	}
+	return m.compare(a, b) == 0
+}
+
+// Return the number of items in the model.
+func (m *Model[T]) Len() int {
+	return len(m.items)
+}
+
+// Return the items in ascending order. The result must not be modified.
+func (m *Model[T]) Items() []T {
+	return m.items
+}
+
+// Return the index of the first item >= key.
+func (m *Model[T]) lowerBound(key T) int {
+	return sort.Search(len(m.items), func(i int) bool { return m.compare(m.items[i], key) >= 0 })
+}
+
+// Return the index of the first item > key.
+func (m *Model[T]) upperBound(key T) int {
+	return sort.Search(len(m.items), func(i int) bool { return m.compare(m.items[i], key) > 0 })
+}
+
+// Insert an item, as Set.Insert does: after any equal items if the
+// model allows duplicates, else only if there is no equal item.
+func (m *Model[T]) Insert(item T) bool {
+	i := m.upperBound(item)
+	if !m.duplicates && i > 0 && m.compare(m.items[i-1], item) == 0 {
+		return false
+	}
+	m.items = append(m.items, item)
+	copy(m.items[i+1:], m.items[i:])
+	m.items[i] = item
+	return true
+}
+
+// Delete the first item equal to key. Return true iff there was one.
+func (m *Model[T]) DeleteWithKey(key T) bool {
+	i := m.lowerBound(key)
+	if i == len(m.items) || m.compare(m.items[i], key) != 0 {
+		return false
+	}
+	m.items = append(m.items[:i], m.items[i+1:]...)
+	return true
+}
+
+// Return the first item >= key, and whether there is one.
+func (m *Model[T]) FindGE(key T) (T, bool) {
+	return m.at(m.lowerBound(key))
+}
+
+// Return the last item <= key, and whether there is one.
+func (m *Model[T]) FindLE(key T) (T, bool) {
+	return m.at(m.upperBound(key) - 1)
+}
+
+func (m *Model[T]) at(i int) (T, bool) {
+	if i < 0 || i >= len(m.items) {
+		var zero T
+		return zero, false
+	}
+	return m.items[i], true
+}
+
+// Check that set holds items equal to the model's, in the same order,
+// scanning it both forward and backward, and that set passes
+// Validate. Items are compared with the function passed to WithEqual, or
+// else with the model's comparison function, in which case an item with
+// the right key but other wrong contents goes unnoticed.
+func Check[T any](set *rbtree.Set[T], m *Model[T]) error {
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	if set.Len() != len(m.items) {
+		return fmt.Errorf("rbtreetest: set has %d items, model has %d", set.Len(), len(m.items))
+	}
+	i := 0
+	for it := set.Min(); !it.Limit(); it = it.Next() {
+		if !m.same(it.Item(), m.items[i]) {
+			return fmt.Errorf("rbtreetest: item %d is %v, model has %v", i, it.Item(), m.items[i])
+		}
+		i++
+	}
+	for it := set.Max(); !it.NegativeLimit(); it = it.Prev() {
+		i--
+		if !m.same(it.Item(), m.items[i]) {
+			return fmt.Errorf("rbtreetest: scanning backward, item %d is %v, model has %v", i, it.Item(), m.items[i])
+		}
+	}
+	return nil
+}
+
+// Apply steps random operations to set and m, using items drawn from
+// gen: Insert, DeleteWithKey, DeleteWithIterator, FindGE and FindLE.
+// Return an error if the set and the model disagree on any result, or
+// if Check fails after any step. Results are reproducible for a given
+// seed and gen.
+func Exercise[T any](set *rbtree.Set[T], m *Model[T], gen func(r *rand.Rand) T, steps int, seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+	for step := 0; step < steps; step++ {
+		key := gen(r)
+		var err error
+		switch op := r.Intn(5); op {
+		case 0:
+			if got, want := set.Insert(key), m.Insert(key); got != want {
+				err = fmt.Errorf("Insert(%v) = %v, model says %v", key, got, want)
+			}
+		case 1:
+			if got, want := set.DeleteWithKey(key), m.DeleteWithKey(key); got != want {
+				err = fmt.Errorf("DeleteWithKey(%v) = %v, model says %v", key, got, want)
+			}
+		case 2:
+			it := set.FindGE(key)
+			if it.Limit() {
+				break
+			}
+			item := it.Item()
+			next := set.DeleteWithIterator(it)
+			m.DeleteWithKey(item)
+			want, ok := m.FindGE(item)
+			if next.Limit() == ok || (ok && !m.same(next.Item(), want)) {
+				err = fmt.Errorf("DeleteWithIterator(FindGE(%v)) returned the wrong successor", key)
+			}
+		case 3:
+			want, ok := m.FindGE(key)
+			it := set.FindGE(key)
+			if it.Limit() == ok || (ok && !m.same(it.Item(), want)) {
+				err = fmt.Errorf("FindGE(%v) disagrees with model", key)
+			}
+		case 4:
+			want, ok := m.FindLE(key)
+			it := set.FindLE(key)
+			if it.NegativeLimit() == ok || (ok && !m.same(it.Item(), want)) {
+				err = fmt.Errorf("FindLE(%v) disagrees with model", key)
+			}
+		}
+		if err == nil {
+			err = Check(set, m)
+		}
+		if err != nil {
+			return fmt.Errorf("rbtreetest: step %d: %w", step, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package rbtreetest
+
+import (
+	"cmp"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/yasushi-saito/rbtree"
+)
+
+func TestCheckComparator(t *testing.T) {
+	ints := []int{-3, 0, 0, 1, 7, 100}
+	if err := CheckComparator(cmp.Compare[int], ints); err != nil {
+		t.Fatal(err)
+	}
+	strs := []string{"", "a", "B", "b", "ab"}
+	if err := CheckComparator(func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}, strs); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		compare func(a, b int) int
+		items   []int
+		want    string
+	}{
+		{"not reflexive", func(a, b int) int {
+			if a <= b {
+				return -1
+			}
+			return 1
+		}, ints, "reflexivity"},
+		// int8(-128) has no positive counterpart.
+		{"overflowing subtraction", func(a, b int) int { return int(int8(a - b)) }, []int{0, 128}, "antisymmetry"},
+		{"wrapping subtraction", func(a, b int) int { return int(int8(a - b)) }, []int{0, 100, -100}, "transitivity"},
+		{"rock paper scissors", func(a, b int) int {
+			if a == b {
+				return 0
+			}
+			if (a+1)%3 == b {
+				return -1
+			}
+			return 1
+		}, []int{0, 1, 2}, "transitivity"},
+		{"equal within 1", func(a, b int) int {
+			if a-b <= 1 && b-a <= 1 {
+				return 0
+			}
+			return cmp.Compare(a, b)
+		}, []int{0, 1, 2}, "transitivity"},
+	} {
+		var cerr *ComparatorError
+		err := CheckComparator(test.compare, test.items)
+		if !errors.As(err, &cerr) || cerr.Property != test.want {
+			t.Errorf("%s: got %v, want a %s violation", test.name, err, test.want)
+		}
+	}
+
+	calls := 0
+	flaky := func(a, b int) int {
+		calls++
+		return calls % 3
+	}
+	var cerr *ComparatorError
+	if err := CheckComparator(flaky, ints); !errors.As(err, &cerr) || cerr.Property != "consistency" {
+		t.Errorf("flaky: got %v", err)
+	}
+}
+
+func TestExercise(t *testing.T) {
+	gen := func(r *rand.Rand) int { return r.Intn(200) }
+	if err := Exercise(rbtree.NewSet[int](), NewModel(cmp.Compare[int], false), gen, 2000, 1); err != nil {
+		t.Fatal(err)
+	}
+	multi := rbtree.NewSet(rbtree.WithDuplicates[int]())
+	if err := Exercise(multi, NewModel(cmp.Compare[int], true), gen, 2000, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// An untyped Tree with a user comparator.
+	type item struct {
+		key   int
+		value string
+	}
+	compare := rbtree.CompareFunc(func(a, b rbtree.Item) int { return cmp.Compare(a.(item).key, b.(item).key) })
+	treeGen := func(r *rand.Rand) rbtree.Item { return item{r.Intn(100), "x"} }
+	if err := Exercise(rbtree.NewTree(compare), NewModel(compare, false), treeGen, 1000, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// A model that disagrees with the set is caught.
+	if err := Exercise(rbtree.NewSet[int](), NewModel(cmp.Compare[int], true), gen, 2000, 1); err == nil {
+		t.Fatal("duplicates mismatch not detected")
+	}
+}
+
+func TestCheckWithEqual(t *testing.T) {
+	type item struct {
+		key, seq int
+	}
+	compare := func(a, b item) int { return cmp.Compare(a.key, b.key) }
+	equal := func(a, b item) bool { return a == b }
+
+	// Equal keys are kept in insertion order, which only a full
+	// comparison can check.
+	seq := 0
+	gen := func(r *rand.Rand) item {
+		seq++
+		return item{r.Intn(50), seq}
+	}
+	multi := rbtree.NewSetFunc(compare, rbtree.WithDuplicates[item]())
+	if err := Exercise(multi, NewModel(compare, true).WithEqual(equal), gen, 2000, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wrong item for a key is caught only with an equality function.
+	set := rbtree.NewSetFunc(compare)
+	set.Insert(item{1, 1})
+	m := NewModel(compare, false)
+	m.Insert(item{1, 2})
+	if err := Check(set, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(set, m.WithEqual(equal)); err == nil {
+		t.Fatal("wrong item not detected")
+	}
+}
@@ -0,0 +1,113 @@
+package rbtree
+
+import "iter"
+
+// Range-over-func iterators.
+//
+// The loop body may delete the item it is currently visiting; the scan
+// continues with the item that followed it. Items inserted during a
+// scan may or may not be visited, and deleting any item other than the
+// current one invalidates the scan.
+
+// Return a sequence of all items in ascending order.
+func (root *Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		root.ascend(root.minNode, nil, yield)
+	}
+}
+
+// Return a sequence of all items in descending order.
+func (root *Set[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		root.descend(root.maxNode, yield)
+	}
+}
+
+// Return a sequence of the items >= from, in ascending order.
+func (root *Set[T]) Ascend(from T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		n, _ := root.findGE(from)
+		root.ascend(n, nil, yield)
+	}
+}
+
+// Return a sequence of the items <= from, in descending order.
+func (root *Set[T]) Descend(from T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		root.descend(root.findLE(from), yield)
+	}
+}
+
+// Return a sequence of the items N such that lo <= N < hi, in
+// ascending order.
+func (root *Set[T]) Range(lo, hi T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		n, _ := root.findGE(lo)
+		root.ascend(n, &hi, yield)
+	}
+}
+
+// Yield items from n upward, stopping before the first item >= *hi
+// if hi is non-nil.
+func (root *Set[T]) ascend(n *node[T], hi *T, yield func(T) bool) {
+	for n != nil {
+		if hi != nil && root.compare(n.item, *hi) >= 0 {
+			return
+		}
+		// Step before yielding so that the body may delete n.
+		next := n.doNext()
+		if !yield(n.item) {
+			return
+		}
+		n = next
+	}
+}
+
+// Yield items from n downward.
+func (root *Set[T]) descend(n *node[T], yield func(T) bool) {
+	for n != nil {
+		prev := n.doPrev()
+		if !yield(n.item) {
+			return
+		}
+		n = prev
+	}
+}
+
+// Return a sequence of all key/value pairs in ascending key order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return entrySeq(m.tree.All())
+}
+
+// Return a sequence of all key/value pairs in descending key order.
+func (m *Map[K, V]) Backward() iter.Seq2[K, V] {
+	return entrySeq(m.tree.Backward())
+}
+
+// Return a sequence of the pairs whose key is >= from, in ascending
+// key order.
+func (m *Map[K, V]) Ascend(from K) iter.Seq2[K, V] {
+	return entrySeq(m.tree.Ascend(mapEntry[K, V]{key: from}))
+}
+
+// Return a sequence of the pairs whose key is <= from, in descending
+// key order.
+func (m *Map[K, V]) Descend(from K) iter.Seq2[K, V] {
+	return entrySeq(m.tree.Descend(mapEntry[K, V]{key: from}))
+}
+
+// Return a sequence of the pairs whose key N satisfies lo <= N < hi,
+// in ascending key order.
+func (m *Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return entrySeq(m.tree.Range(mapEntry[K, V]{key: lo}, mapEntry[K, V]{key: hi}))
+}
+
+func entrySeq[K, V any](seq iter.Seq[mapEntry[K, V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range seq {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package rbtree
+
+import (
+	"fmt"
+	"iter"
+	"testing"
+)
+
+func seqToString[T any](seq iter.Seq[T]) string {
+	s := ""
+	for item := range seq {
+		if s != "" {
+			s += ","
+		}
+		s += fmt.Sprint(item)
+	}
+	return s
+}
+
+func TestSeq(t *testing.T) {
+	tree := testNewIntSet()
+	testAssert(t, seqToString(tree.All()) == "", "empty All")
+	testAssert(t, seqToString(tree.Backward()) == "", "empty Backward")
+	for i := 0; i < 10; i = i + 2 {
+		tree.Insert(i)
+	}
+	for _, test := range []struct {
+		seq  iter.Seq[Item]
+		want string
+	}{
+		{tree.All(), "0,2,4,6,8"},
+		{tree.Backward(), "8,6,4,2,0"},
+		{tree.Ascend(3), "4,6,8"},
+		{tree.Ascend(4), "4,6,8"},
+		{tree.Ascend(9), ""},
+		{tree.Descend(3), "2,0"},
+		{tree.Descend(4), "4,2,0"},
+		{tree.Descend(-1), ""},
+		{tree.Range(2, 6), "2,4"},
+		{tree.Range(1, 7), "2,4,6"},
+		{tree.Range(6, 2), ""},
+	} {
+		if got := seqToString(test.seq); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestSeqBreak(t *testing.T) {
+	s := NewSet[int]()
+	for i := 0; i < 10; i++ {
+		s.Insert(i)
+	}
+	n := 0
+	for item := range s.All() {
+		if item == 3 {
+			break
+		}
+		n++
+	}
+	testAssert(t, n == 3, "break")
+}
+
+func TestSeqDeleteCurrent(t *testing.T) {
+	s := NewSet[int]()
+	for i := 0; i < 100; i++ {
+		s.Insert(i)
+	}
+	visited := 0
+	for item := range s.All() {
+		visited++
+		if item%3 != 0 {
+			s.DeleteWithKey(item)
+		}
+	}
+	testAssert(t, visited == 100, "visited all")
+	testAssert(t, s.Len() == 34, "len after forward delete")
+
+	visited = 0
+	for item := range s.Backward() {
+		visited++
+		if item%2 != 0 {
+			s.DeleteWithKey(item)
+		}
+	}
+	testAssert(t, visited == 34, "visited all backward")
+	testAssert(t, seqToString(s.Range(0, 20)) == "0,6,12,18", seqToString(s.Range(0, 20)))
+}
+
+func TestMapSeq(t *testing.T) {
+	m := NewMap[int, string]()
+	for i := 0; i < 5; i++ {
+		m.Insert(i, fmt.Sprint("v", i))
+	}
+	s := ""
+	for k, v := range m.Range(1, 4) {
+		s += fmt.Sprintf("%d=%s,", k, v)
+	}
+	testAssert(t, s == "1=v1,2=v2,3=v3,", s)
+	s = ""
+	for k := range m.Descend(2) {
+		s += fmt.Sprint(k)
+	}
+	testAssert(t, s == "210", s)
+	s = ""
+	for k := range m.Backward() {
+		s += fmt.Sprint(k)
+	}
+	testAssert(t, s == "43210", s)
+}
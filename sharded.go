@@ -0,0 +1,334 @@
+package rbtree
+
+import (
+	"cmp"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// ShardedTree is a ShardedSet of untyped Items.
+type ShardedTree = ShardedSet[Item]
+
+// ShardedSet is a concurrent ordered set that partitions the key space
+// into contiguous ranges, each held in its own Set behind its own lock,
+// so that writers to different ranges do not contend.
+//
+// A shard that grows beyond the configured size is split in two near
+// its median, unless all its items are equal, and a shard that shrinks
+// below a quarter of it is merged with a neighbor. Both use Split and
+// Join, so they take O(log n) time in the size of the shards, but they
+// briefly block all other operations.
+//
+// Lookups and updates lock only the shard they touch. Scans copy items
+// out of the shards a few hundred at a time and hold no lock while the
+// loop body runs, so the body may call any method, including ones that
+// modify the ShardedSet. A scan yields each item at most once and in
+// ascending order, but it is not a snapshot: it may or may not observe
+// updates made while it runs.
+//
+// Since a NodePool is not safe for concurrent use, a pool passed with
+// WithNodePool is used only by the first shard, and each shard created
+// by a split gets a new pool with the same slab size.
+type ShardedSet[T any] struct {
+	// Protects shards. Held for reading by every operation, and for
+	// writing when shards are split or merged.
+	mu        sync.RWMutex
+	shards    []*shard[T]
+	compare   func(a, b T) int
+	shardSize int
+}
+
+type shard[T any] struct {
+	// The smallest item the shard may hold. Unused for the first shard,
+	// which has no lower bound.
+	lo   T
+	mu   sync.RWMutex
+	tree *Set[T]
+}
+
+// Used if the shard size passed to a constructor is not positive.
+const defaultShardSize = 1 << 16
+
+// Create a new empty sharded tree whose shards hold up to shardSize
+// items each.
+func NewShardedTree(compare CompareFunc, shardSize int, opts ...Option[Item]) *ShardedTree {
+	return NewShardedSetFunc[Item](compare, shardSize, opts...)
+}
+
+// Create a new empty sharded set whose shards hold up to shardSize items
+// each. compare returns 0 if a==b, <0 if a<b, >0 if a>b.
+func NewShardedSetFunc[T any](compare func(a, b T) int, shardSize int, opts ...Option[T]) *ShardedSet[T] {
+	if shardSize <= 0 {
+		shardSize = defaultShardSize
+	}
+	return &ShardedSet[T]{
+		shards:    []*shard[T]{{tree: NewSetFunc(compare, opts...)}},
+		compare:   compare,
+		shardSize: shardSize,
+	}
+}
+
+// Create a new empty sharded set ordered by the natural order of T.
+func NewShardedSet[T cmp.Ordered](shardSize int, opts ...Option[T]) *ShardedSet[T] {
+	return NewShardedSetFunc(cmp.Compare[T], shardSize, opts...)
+}
+
+// Return the number of elements in the tree.
+func (s *ShardedSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		n += sh.tree.Len()
+		sh.mu.RUnlock()
+	}
+	return n
+}
+
+// Return the current number of shards.
+func (s *ShardedSet[T]) NumShards() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.shards)
+}
+
+// Find an element equal to key. The 2nd return value is true iff such
+// an element exists.
+func (s *ShardedSet[T]) Lookup(key T) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sh := s.shards[s.shardIndex(key)]
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.tree.Lookup(key)
+}
+
+// A convenience function for finding an element equal to key. Return
+// the zero value of T if not found.
+func (s *ShardedSet[T]) Get(key T) T {
+	item, _ := s.Lookup(key)
+	return item
+}
+
+// Insert an item. See Set.Insert.
+func (s *ShardedSet[T]) Insert(item T) bool {
+	split := false
+	inserted, _, sh := s.write(item, func(tree *Set[T]) bool {
+		inserted := tree.Insert(item)
+		if tree.Len() > s.shardSize {
+			_, split = s.splitKey(tree)
+		}
+		return inserted
+	})
+	if split {
+		s.splitShard(sh)
+	}
+	return inserted
+}
+
+// Delete an item with the given key. Return true iff the item was
+// found.
+func (s *ShardedSet[T]) DeleteWithKey(key T) bool {
+	deleted, size, sh := s.write(key, func(tree *Set[T]) bool { return tree.DeleteWithKey(key) })
+	if deleted && size < s.shardSize/4 {
+		s.mergeShard(sh)
+	}
+	return deleted
+}
+
+// Return a sequence of all items in ascending order.
+func (s *ShardedSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.scan(nil, nil, yield)
+	}
+}
+
+// Return a sequence of the items >= from, in ascending order.
+func (s *ShardedSet[T]) Ascend(from T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.scan(&from, nil, yield)
+	}
+}
+
+// Return a sequence of the items N such that lo <= N < hi, in ascending
+// order.
+func (s *ShardedSet[T]) Range(lo, hi T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.scan(&lo, &hi, yield)
+	}
+}
+
+// Number of items a scan copies out of the shards at a time.
+const scanBatchSize = 256
+
+// Yield the items N such that lo <= N < hi, where a nil bound is
+// unlimited. The items are copied out a batch at a time, and no lock is
+// held while they are yielded. Each batch resumes after the last item of
+// the previous one, looking its shard up anew, so shards may be split or
+// merged in between.
+func (s *ShardedSet[T]) scan(lo, hi *T, yield func(T) bool) {
+	var batch []T
+	after := false
+	for {
+		var more bool
+		batch, more = s.nextBatch(batch[:0], lo, after, hi)
+		for _, item := range batch {
+			if !yield(item) {
+				return
+			}
+		}
+		if !more {
+			return
+		}
+		last := batch[len(batch)-1]
+		lo, after = &last, true
+	}
+}
+
+// Append to batch about scanBatchSize of the items N such that lo <= N
+// (or lo < N if after is set) and N < hi, in ascending order. Items equal
+// to the last one are always included, so that resuming after it does not
+// skip duplicates. Return the batch and whether there may be more items.
+func (s *ShardedSet[T]) nextBatch(batch []T, lo *T, after bool, hi *T) ([]T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	start := 0
+	if lo != nil {
+		start = s.shardIndex(*lo)
+	}
+	for _, sh := range s.shards[start:] {
+		more, done := s.batchFromShard(sh, &batch, lo, after, hi)
+		if done {
+			return batch, more
+		}
+	}
+	return batch, false
+}
+
+// Append the items of sh in range to *batch, stopping when it is full.
+// Return done if the batch is full or the end of the range was reached,
+// and more if there may be items after the batch.
+func (s *ShardedSet[T]) batchFromShard(sh *shard[T], batch *[]T, lo *T, after bool, hi *T) (more, done bool) {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	it := sh.tree.Min()
+	if lo != nil {
+		it = sh.tree.FindGE(*lo)
+	}
+	for ; !it.Limit(); it = it.Next() {
+		item := it.Item()
+		if after && s.compare(item, *lo) == 0 {
+			continue
+		}
+		if hi != nil && s.compare(item, *hi) >= 0 {
+			return false, true
+		}
+		if n := len(*batch); n >= scanBatchSize && s.compare((*batch)[n-1], item) != 0 {
+			return true, true
+		}
+		*batch = append(*batch, item)
+	}
+	return false, false
+}
+
+// Apply op to the shard that owns key under its write lock. Return
+// op's result, the shard's size afterwards, and the shard.
+func (s *ShardedSet[T]) write(key T, op func(*Set[T]) bool) (bool, int, *shard[T]) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sh := s.shards[s.shardIndex(key)]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return op(sh.tree), sh.tree.Len(), sh
+}
+
+// Return the index of the shard that owns key.
+//
+// REQUIRES: s.mu is held.
+func (s *ShardedSet[T]) shardIndex(key T) int {
+	// The last shard whose lower bound is <= key. Shard 0 has none.
+	return sort.Search(len(s.shards)-1, func(i int) bool {
+		return s.compare(s.shards[i+1].lo, key) > 0
+	})
+}
+
+// Return the index of sh, or -1 if it has been merged away.
+//
+// REQUIRES: s.mu is held.
+func (s *ShardedSet[T]) indexOf(sh *shard[T]) int {
+	for i, other := range s.shards {
+		if other == sh {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return a key near the median of tree that splits it into two
+// nonempty parts, or false if there is none because all the items are
+// equal. Equal items always stay in the same shard.
+func (s *ShardedSet[T]) splitKey(tree *Set[T]) (T, bool) {
+	median := tree.Select(tree.Len() / 2).Item()
+	if s.compare(tree.Min().Item(), median) < 0 {
+		return median, true
+	}
+	// Every item before the median equals it, so split after them.
+	if n := tree.findGT(median); n != nil {
+		return n.item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Split sh near its median if it is still too large and can be split.
+func (s *ShardedSet[T]) splitShard(sh *shard[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(sh)
+	if i < 0 || sh.tree.Len() <= s.shardSize {
+		return
+	}
+	median, ok := s.splitKey(sh.tree)
+	if !ok {
+		return
+	}
+	left, right := sh.tree.Split(median)
+	if right.pool != nil {
+		// Shards are locked separately, so they must not share a pool.
+		right.pool = NewNodePool[T](right.pool.slabSize)
+	}
+	sh.tree = left
+	s.shards = append(s.shards, nil)
+	copy(s.shards[i+2:], s.shards[i+1:])
+	s.shards[i+1] = &shard[T]{lo: median, tree: right}
+}
+
+// Merge sh with a neighbor if it is still small and the result would
+// not be too large.
+func (s *ShardedSet[T]) mergeShard(sh *shard[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexOf(sh)
+	if i < 0 || len(s.shards) == 1 || sh.tree.Len() >= s.shardSize/4 {
+		return
+	}
+	if i == len(s.shards)-1 {
+		i--
+	}
+	left, right := s.shards[i], s.shards[i+1]
+	if left.tree.Len()+right.tree.Len() > s.shardSize {
+		return
+	}
+	switch {
+	case right.tree.Len() == 0:
+	case left.tree.Len() == 0:
+		left.tree = right.tree
+	default:
+		pivot := right.tree.Min().Item()
+		right.tree.DeleteWithKey(pivot)
+		left.tree = Join(left.tree, pivot, right.tree)
+	}
+	s.shards = append(s.shards[:i+1], s.shards[i+2:]...)
+}
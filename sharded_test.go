@@ -0,0 +1,179 @@
+package rbtree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Check that the shards are ordered, that each holds only items in its
+// range, and that each is a valid tree.
+func checkShards[T any](t *testing.T, s *ShardedSet[T]) {
+	for i, sh := range s.shards {
+		checkSizes(t, sh.tree.root)
+		checkRedBlack(t, sh.tree.root)
+		if i == 0 || sh.tree.Len() == 0 {
+			continue
+		}
+		if s.compare(sh.tree.Min().Item(), sh.lo) < 0 {
+			t.Fatalf("shard %d holds an item below its lower bound", i)
+		}
+		if prev := s.shards[i-1].tree; prev.Len() > 0 && s.compare(prev.Max().Item(), sh.lo) >= 0 {
+			t.Fatalf("shard %d holds an item above its upper bound", i-1)
+		}
+	}
+}
+
+func TestShardedSplitMerge(t *testing.T) {
+	s := NewShardedSet[int](16)
+	keys := rand.New(rand.NewSource(0)).Perm(1000)
+	for _, k := range keys {
+		testAssert(t, s.Insert(k), "insert")
+	}
+	testAssert(t, !s.Insert(keys[0]), "insert dup")
+	checkShards(t, s)
+	testAssert(t, s.Len() == 1000, "len")
+	testAssert(t, s.NumShards() >= 1000/16, "shards split")
+	for i, item := range slices.Collect(s.All()) {
+		testAssert(t, item == i, "all")
+	}
+	testAssert(t, slices.Equal(slices.Collect(s.Range(95, 105)), []int{95, 96, 97, 98, 99, 100, 101, 102, 103, 104}), "range")
+	got := []int{}
+	for item := range s.Ascend(990) {
+		if item > 992 {
+			break
+		}
+		got = append(got, item)
+	}
+	testAssert(t, slices.Equal(got, []int{990, 991, 992}), "ascend")
+	v, ok := s.Lookup(500)
+	testAssert(t, ok && v == 500, "lookup")
+	_, ok = s.Lookup(1000)
+	testAssert(t, !ok, "lookup missing")
+
+	for _, k := range keys[:990] {
+		testAssert(t, s.DeleteWithKey(k), "delete")
+	}
+	testAssert(t, !s.DeleteWithKey(keys[0]), "delete again")
+	checkShards(t, s)
+	testAssert(t, s.Len() == 10, "len after delete")
+	testAssert(t, s.NumShards() == 1, "shards merged")
+	testAssert(t, slices.IsSorted(slices.Collect(s.All())), "sorted after merge")
+}
+
+// The loop body of a scan may call any method, even while it splits and
+// merges shards.
+func TestShardedScanCallsBack(t *testing.T) {
+	s := NewShardedSet[int](16)
+	for i := 0; i < 2000; i += 2 {
+		s.Insert(i)
+	}
+	var got []int
+	for item := range s.All() {
+		got = append(got, item)
+		_, ok := s.Lookup(item)
+		testAssert(t, ok && s.Len() > 0, "lookup in loop")
+		if item%2 == 0 {
+			s.Insert(item + 1)
+			s.DeleteWithKey(item)
+		}
+	}
+	// The odd items may or may not be seen, but every even item is seen
+	// once, in order.
+	evens := 0
+	for i, item := range got {
+		testAssert(t, i == 0 || got[i-1] < item, "in order, each once")
+		if item%2 == 0 {
+			evens++
+		}
+	}
+	testAssert(t, evens == 1000, "visited every item")
+	checkShards(t, s)
+	testAssert(t, s.Len() == 1000 && s.NumShards() > 1, "len")
+}
+
+func TestShardedScanDuplicates(t *testing.T) {
+	s := NewShardedSet(1000, WithDuplicates[int]())
+	for i := 0; i < 300; i++ {
+		s.Insert(i / 100)
+	}
+	got := slices.Collect(s.Range(0, 2))
+	testAssert(t, len(got) == 200 && slices.IsSorted(got), "duplicates across batches")
+}
+
+// Equal items cannot be split between shards, so a shard of them grows
+// past the shard size instead.
+func TestShardedAllDuplicates(t *testing.T) {
+	s := NewShardedSet(4, WithDuplicates[int]())
+	for i := 0; i < 100; i++ {
+		s.Insert(7)
+	}
+	testAssert(t, s.Len() == 100 && s.NumShards() == 1, "one shard")
+
+	// A run of equal items at the start of a shard is split off whole.
+	for i := 0; i < 20; i++ {
+		s.Insert(8 + i%5)
+	}
+	checkShards(t, s)
+	testAssert(t, s.NumShards() > 1 && s.NumShards() <= 6, "split between distinct items")
+	for _, sh := range s.shards {
+		testAssert(t, sh.tree.Len() <= 4 || sh.tree.Min().Item() == sh.tree.Max().Item(), "only equal items overflow")
+	}
+	testAssert(t, len(slices.Collect(s.Range(7, 8))) == 100, "all duplicates kept")
+}
+
+// Run with -race.
+func TestShardedConcurrent(t *testing.T) {
+	t.Run("plain", func(t *testing.T) { testShardedConcurrent(t, NewShardedSet[int](32)) })
+	// Every shard must get a pool of its own.
+	t.Run("pooled", func(t *testing.T) {
+		testShardedConcurrent(t, NewShardedSet(32, WithNodePool(NewNodePool[int](0))))
+	})
+}
+
+func testShardedConcurrent(t *testing.T, s *ShardedSet[int]) {
+	const (
+		numWriters = 4
+		numOps     = 2000
+	)
+	var wg sync.WaitGroup
+	for w := 0; w < numWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numOps; i++ {
+				key := i*numWriters + w
+				s.Insert(key)
+				if i%3 == 0 {
+					s.DeleteWithKey(key)
+				}
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numOps/10; i++ {
+			prev := -1
+			for item := range s.All() {
+				if item <= prev {
+					t.Errorf("out of order: %d after %d", item, prev)
+				}
+				prev = item
+			}
+			s.Get(i)
+			s.Len()
+		}
+	}()
+	wg.Wait()
+	checkShards(t, s)
+
+	want := 0
+	for i := 0; i < numOps; i++ {
+		if i%3 != 0 {
+			want += numWriters
+		}
+	}
+	testAssert(t, s.Len() == want, "len")
+}
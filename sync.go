@@ -0,0 +1,188 @@
+package rbtree
+
+import (
+	"cmp"
+	"sync"
+)
+
+// SyncTree is a SyncSet of untyped Items.
+type SyncTree = SyncSet[Item]
+
+// SyncSet wraps a Set with a reader/writer lock so that it can be used
+// from multiple goroutines.
+//
+// Lookups (Len, Get, Lookup, Min, Max, Rank) and scans (Ascend,
+// Descend, Range, View) take the read lock, so any number of them can
+// run concurrently. Insert, DeleteWithKey, Replace, Update and Mutate
+// take the write lock and exclude all other operations.
+//
+// Scans hold the read lock for their whole duration, so the callback
+// sees a consistent snapshot of the tree, and writers wait until the
+// scan finishes. Callbacks, including those passed to Update and
+// Mutate, must not call any method of the same SyncSet, not even a
+// lookup: sync.RWMutex does not allow the read lock to be taken again
+// while a writer is waiting, so that can deadlock. Use the *Set passed
+// to View and Mutate instead.
+//
+// SyncSet hands out items, never iterators, because an iterator would
+// outlive the lock that protects it.
+type SyncSet[T any] struct {
+	mu   sync.RWMutex
+	tree *Set[T]
+}
+
+// Create a new empty synchronized tree.
+func NewSyncTree(compare CompareFunc, opts ...Option[Item]) *SyncTree {
+	return NewSyncSetFunc[Item](compare, opts...)
+}
+
+// Create a new empty synchronized set. compare returns 0 if a==b, <0
+// if a<b, >0 if a>b.
+func NewSyncSetFunc[T any](compare func(a, b T) int, opts ...Option[T]) *SyncSet[T] {
+	return &SyncSet[T]{tree: NewSetFunc(compare, opts...)}
+}
+
+// Create a new empty synchronized set ordered by the natural order of
+// T.
+func NewSyncSet[T cmp.Ordered](opts ...Option[T]) *SyncSet[T] {
+	return NewSyncSetFunc(cmp.Compare[T], opts...)
+}
+
+// Return the number of elements in the tree.
+func (s *SyncSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Len()
+}
+
+// A convenience function for finding an element equal to key. Return
+// the zero value of T if not found.
+func (s *SyncSet[T]) Get(key T) T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Get(key)
+}
+
+// Find an element equal to key. The 2nd return value is true iff such
+// an element exists.
+func (s *SyncSet[T]) Lookup(key T) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Lookup(key)
+}
+
+// Return the minimum item. The 2nd return value is false if the tree is
+// empty.
+func (s *SyncSet[T]) Min() (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return itemAt(s.tree.Min())
+}
+
+// Return the maximum item. The 2nd return value is false if the tree is
+// empty.
+func (s *SyncSet[T]) Max() (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return itemAt(s.tree.Max())
+}
+
+// Return the number of items < key.
+func (s *SyncSet[T]) Rank(key T) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Rank(key)
+}
+
+// Insert an item. See Set.Insert.
+func (s *SyncSet[T]) Insert(item T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.Insert(item)
+}
+
+// Delete an item with the given key. Return true iff the item was
+// found.
+func (s *SyncSet[T]) DeleteWithKey(key T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.DeleteWithKey(key)
+}
+
+// Insert item, or overwrite the equal item already in the tree. See
+// Set.Replace.
+func (s *SyncSet[T]) Replace(item T) (old T, replaced bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.Replace(item)
+}
+
+// Atomically read, modify and write the item equal to key. See
+// Set.Update.
+func (s *SyncSet[T]) Update(key T, fn func(old T, found bool) (item T, keep bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tree.Update(key, fn)
+}
+
+// Call fn for each item >= from in ascending order, until fn returns
+// false.
+func (s *SyncSet[T]) Ascend(from T, fn func(item T) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for item := range s.tree.Ascend(from) {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// Call fn for each item <= from in descending order, until fn returns
+// false.
+func (s *SyncSet[T]) Descend(from T, fn func(item T) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for item := range s.tree.Descend(from) {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// Call fn for each item N such that lo <= N < hi in ascending order,
+// until fn returns false.
+func (s *SyncSet[T]) Range(lo, hi T, fn func(item T) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for item := range s.tree.Range(lo, hi) {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// Call fn with the underlying tree under the read lock. fn must not
+// modify the tree or let iterators escape.
+func (s *SyncSet[T]) View(fn func(tree *Set[T])) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.tree)
+}
+
+// Call fn with the underlying tree under the write lock, so that it can
+// apply several changes atomically. fn must not let iterators escape.
+func (s *SyncSet[T]) Mutate(fn func(tree *Set[T])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.tree)
+}
+
+// Return the item iter points to. The 2nd return value is false if iter
+// is at a limit.
+func itemAt[T any](iter SetIterator[T]) (T, bool) {
+	if iter.Limit() || iter.NegativeLimit() {
+		var zero T
+		return zero, false
+	}
+	return iter.Item(), true
+}
@@ -0,0 +1,100 @@
+package rbtree
+
+import (
+	"sync"
+	"testing"
+)
+
+// Run with -race to check that the operations SyncSet documents as
+// concurrent really are.
+func TestSyncSetConcurrent(t *testing.T) {
+	const (
+		numWriters = 4
+		numReaders = 4
+		numOps     = 2000
+	)
+	s := NewSyncSet[int]()
+	var wg sync.WaitGroup
+	for w := 0; w < numWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numOps; i++ {
+				key := i*numWriters + w
+				s.Insert(key)
+				if i%3 == 0 {
+					s.DeleteWithKey(key)
+				}
+				s.Update(-1, func(n int, found bool) (int, bool) { return -1, true })
+			}
+		}(w)
+	}
+	for r := 0; r < numReaders; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numOps/10; i++ {
+				// A scan sees a consistent tree: strictly increasing
+				// items, as many as Len reports.
+				s.View(func(tree *Set[int]) {
+					n, prev := 0, -2
+					for item := range tree.All() {
+						if item <= prev {
+							t.Errorf("out of order: %d after %d", item, prev)
+						}
+						prev = item
+						n++
+					}
+					if n != tree.Len() {
+						t.Errorf("scanned %d items, Len() = %d", n, tree.Len())
+					}
+				})
+				s.Range(0, 100, func(item int) bool { return item < 50 })
+				s.Get(i)
+				s.Min()
+				s.Max()
+				s.Len()
+				s.Rank(i)
+				// DumpAsString used to write a package variable.
+				if i%50 == 0 {
+					s.View(func(tree *Set[int]) { tree.DumpAsString() })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := 1 // the -1 written by Update
+	for i := 0; i < numOps; i++ {
+		if i%3 != 0 {
+			want += numWriters
+		}
+	}
+	testAssert(t, s.Len() == want, "len")
+}
+
+func TestSyncSet(t *testing.T) {
+	s := NewSyncTree(testNewIntSet().compare)
+	_, ok := s.Min()
+	testAssert(t, !ok, "empty min")
+	for i := 0; i < 10; i++ {
+		s.Insert(i)
+	}
+	min, _ := s.Min()
+	max, _ := s.Max()
+	testAssert(t, min.(int) == 0 && max.(int) == 9, "min/max")
+	got := []int{}
+	s.Descend(5, func(item Item) bool {
+		got = append(got, item.(int))
+		return len(got) < 3
+	})
+	testAssert(t, len(got) == 3 && got[0] == 5 && got[2] == 3, "descend")
+	s.Mutate(func(tree *Tree) {
+		for item := range tree.All() {
+			if item.(int)%2 == 1 {
+				tree.DeleteWithKey(item)
+			}
+		}
+	})
+	testAssert(t, s.Len() == 5, "mutate")
+}
@@ -0,0 +1,90 @@
+package rbtree
+
+// Read-modify-write operations. Each of these descends the tree once.
+// In a tree that allows duplicates, they act on the first equal item.
+
+// Insert item, or overwrite the equal item already in the tree. If an
+// item was overwritten, return it and true. Else return the zero value
+// and false.
+func (root *Set[T]) Replace(item T) (old T, replaced bool) {
+	found, parent, comp := root.locate(item)
+	if found != nil {
+		old = found.item
+		found.item = item
+		root.updatePath(found)
+		return old, true
+	}
+	root.insertFixup(root.link(item, parent, comp))
+	return old, false
+}
+
+// Insert item unless an equal item is already in the tree. Return an
+// iterator to the item in the tree, and true iff it is the newly
+// inserted one. This is like C++ std::map::insert.
+func (root *Set[T]) InsertOrGet(item T) (SetIterator[T], bool) {
+	found, parent, comp := root.locate(item)
+	if found != nil {
+		return root.iterator(found), false
+	}
+	n := root.link(item, parent, comp)
+	root.insertFixup(n)
+	return root.iterator(n), true
+}
+
+// Look up key and call fn with the item found (or the zero value) and
+// whether it was found. If fn returns keep=false, the item is deleted
+// (if it was there). Otherwise the returned item is stored, replacing
+// the old one or inserted as new.
+//
+// REQUIRES: the item returned by fn compares equal to key, and fn does
+// not modify the tree.
+func (root *Set[T]) Update(key T, fn func(old T, found bool) (item T, keep bool)) {
+	found, parent, comp := root.locate(key)
+	var old T
+	if found != nil {
+		old = found.item
+	}
+	item, keep := fn(old, found != nil)
+	if !keep {
+		if found != nil {
+			root.doDelete(found)
+		}
+		return
+	}
+	if root.compare(key, item) != 0 {
+		panic("rbtree: Update callback returned an item that does not match the key")
+	}
+	if found != nil {
+		found.item = item
+		root.updatePath(found)
+		return
+	}
+	root.insertFixup(root.link(item, parent, comp))
+}
+
+// Insert a key and its value, or overwrite the value of an existing
+// key. If a value was overwritten, return it and true.
+func (m *Map[K, V]) Replace(key K, value V) (old V, replaced bool) {
+	e, replaced := m.tree.Replace(mapEntry[K, V]{key, value})
+	return e.value, replaced
+}
+
+// Insert a key and its value unless the key is already in the map.
+// Return an iterator to the key's element, and true iff it was newly
+// inserted.
+func (m *Map[K, V]) InsertOrGet(key K, value V) (MapIterator[K, V], bool) {
+	iter, inserted := m.tree.InsertOrGet(mapEntry[K, V]{key, value})
+	return MapIterator[K, V]{iter}, inserted
+}
+
+// Look up key and call fn with its value (or the zero value) and
+// whether it was found. If fn returns keep=false, the key is deleted.
+// Otherwise the returned value is stored under key.
+//
+// REQUIRES: fn does not modify the map.
+func (m *Map[K, V]) Update(key K, fn func(old V, found bool) (value V, keep bool)) {
+	m.tree.Update(mapEntry[K, V]{key: key}, func(old mapEntry[K, V], found bool) (mapEntry[K, V], bool) {
+		value, keep := fn(old.value, found)
+		return mapEntry[K, V]{key, value}, keep
+	})
+}
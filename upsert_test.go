@@ -0,0 +1,76 @@
+package rbtree
+
+import "testing"
+
+type kv struct {
+	key   int
+	value string
+}
+
+func newKVSet() *Set[kv] {
+	return NewSetFunc(func(a, b kv) int { return a.key - b.key })
+}
+
+func TestReplace(t *testing.T) {
+	s := newKVSet()
+	_, replaced := s.Replace(kv{1, "a"})
+	testAssert(t, !replaced, "replace new")
+	old, replaced := s.Replace(kv{1, "b"})
+	testAssert(t, replaced && old.value == "a", "replace existing")
+	testAssert(t, s.Get(kv{key: 1}).value == "b", "get")
+	testAssert(t, s.Len() == 1, "len")
+}
+
+func TestInsertOrGet(t *testing.T) {
+	s := newKVSet()
+	s.Insert(kv{2, "x"})
+	it, inserted := s.InsertOrGet(kv{1, "a"})
+	testAssert(t, inserted && it.Item().value == "a", "insert new")
+	testAssert(t, it.Next().Item().key == 2, "iterator position")
+	it, inserted = s.InsertOrGet(kv{1, "b"})
+	testAssert(t, !inserted && it.Item().value == "a", "get existing")
+	testAssert(t, s.Len() == 2, "len")
+}
+
+func TestUpdate(t *testing.T) {
+	s := NewSet(WithAugmenter[int](AugmenterFunc[int, int](sumAugmenter)))
+	insert5 := func(old int, found bool) (int, bool) {
+		testAssert(t, !found, "found")
+		return 5, true
+	}
+	s.Update(5, insert5)
+	testAssert(t, s.Len() == 1 && s.Get(5) == 5, "insert")
+	s.Update(5, func(old int, found bool) (int, bool) {
+		testAssert(t, found && old == 5, "found old")
+		return old, false
+	})
+	testAssert(t, s.Len() == 0, "delete")
+	s.Update(6, func(old int, found bool) (int, bool) { return 0, false })
+	testAssert(t, s.Len() == 0, "no-op")
+
+	for i := 0; i < 100; i++ {
+		s.Update(i, func(old int, found bool) (int, bool) { return i, true })
+		checkSums(t, s.root)
+	}
+	testAssert(t, s.Len() == 100, "len")
+
+	testPanics(t, func() { s.Update(200, func(int, bool) (int, bool) { return 201, true }) },
+		"rbtree: Update callback returned an item that does not match the key")
+}
+
+func TestMapUpdate(t *testing.T) {
+	m := NewMap[string, int]()
+	words := []string{"a", "b", "a", "c", "a", "b"}
+	for _, w := range words {
+		m.Update(w, func(n int, found bool) (int, bool) { return n + 1, true })
+	}
+	n, _ := m.Get("a")
+	testAssert(t, n == 3, "count a")
+	old, replaced := m.Replace("a", 10)
+	testAssert(t, replaced && old == 3, "replace")
+	it, inserted := m.InsertOrGet("b", 100)
+	testAssert(t, !inserted && it.Value() == 2, "InsertOrGet")
+	m.Update("c", func(int, bool) (int, bool) { return 0, false })
+	_, ok := m.Get("c")
+	testAssert(t, !ok && m.Len() == 2, "delete c")
+}
@@ -0,0 +1,157 @@
+package rbtree
+
+import "fmt"
+
+// Invariant names a property of a valid tree.
+type Invariant string
+
+const (
+	// Every child's parent pointer points back to its parent.
+	InvariantParent Invariant = "parent link"
+	// Items are in increasing order under compare (non-decreasing in a
+	// tree that allows duplicates).
+	InvariantOrder Invariant = "order"
+	// Every node is red or black.
+	InvariantColor Invariant = "color"
+	// The root is black.
+	InvariantRootBlack Invariant = "black root"
+	// A red node has no red child.
+	InvariantRedRed Invariant = "red-red"
+	// Every path from a node down to a nil child has the same number of
+	// black nodes.
+	InvariantBlackHeight Invariant = "black height"
+	// Each node records the number of nodes in its subtree.
+	InvariantSize Invariant = "subtree size"
+	// Len() is the number of nodes in the tree.
+	InvariantCount Invariant = "count"
+	// Min() and Max() point to the first and last nodes.
+	InvariantMinMax Invariant = "min/max"
+)
+
+// ValidationError describes the first invariant violation found by
+// Validate.
+type ValidationError struct {
+	Invariant Invariant
+	// The path from the root to the offending node, as a string of 'L'
+	// and 'R' steps. Empty for the root and for violations that concern
+	// the tree as a whole.
+	Path   string
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	where := "root"
+	if e.Path != "" {
+		where = "path " + e.Path
+	}
+	return fmt.Sprintf("rbtree: %s invariant violated at %s: %s", e.Invariant, where, e.Detail)
+}
+
+// Check every invariant of the tree: parent links, ordering under
+// compare, the red-black coloring rules, subtree sizes, Len, Min and
+// Max. Return nil if the tree is valid, or else a *ValidationError
+// describing the first violation found. This takes O(n) time and is
+// meant for tests.
+func (root *Set[T]) Validate() error {
+	v := validator[T]{root: root}
+	if root.root != nil {
+		if root.root.parent != nil {
+			return v.errorf(InvariantParent, "root has a parent")
+		}
+		if root.root.color != black {
+			return v.errorf(InvariantRootBlack, "root %v is not black", root.root.item)
+		}
+	}
+	if _, err := v.check(root.root); err != nil {
+		return err
+	}
+	v.path = v.path[:0]
+	if v.count != root.count {
+		return v.errorf(InvariantCount, "Len() is %d, but the tree has %d nodes", root.count, v.count)
+	}
+	if root.minNode != v.first {
+		return v.errorf(InvariantMinMax, "min does not point to the first node")
+	}
+	if root.maxNode != v.prev {
+		return v.errorf(InvariantMinMax, "max does not point to the last node")
+	}
+	return nil
+}
+
+// Check the invariants of the tree under the map. See Set.Validate.
+func (m *Map[K, V]) Validate() error {
+	return m.tree.Validate()
+}
+
+type validator[T any] struct {
+	root *Set[T]
+	// Path from the root to the node being checked.
+	path []byte
+	// The first node and the most recent node visited in order.
+	first, prev *node[T]
+	count       int
+}
+
+func (v *validator[T]) errorf(inv Invariant, format string, args ...interface{}) error {
+	return &ValidationError{Invariant: inv, Path: string(v.path), Detail: fmt.Sprintf(format, args...)}
+}
+
+// Check the subtree at n, visiting it in order, and return its black
+// height.
+func (v *validator[T]) check(n *node[T]) (int, error) {
+	if n == nil {
+		return 1, nil
+	}
+	if n.color != red && n.color != black {
+		return 0, v.errorf(InvariantColor, "node %v has color %d", n.item, n.color)
+	}
+	for _, c := range []struct {
+		child *node[T]
+		step  byte
+	}{{n.left, 'L'}, {n.right, 'R'}} {
+		if c.child == nil {
+			continue
+		}
+		if c.child.parent != n {
+			v.path = append(v.path, c.step)
+			return 0, v.errorf(InvariantParent, "node %v does not point back to its parent %v", c.child.item, n.item)
+		}
+		if n.color == red && c.child.color == red {
+			v.path = append(v.path, c.step)
+			return 0, v.errorf(InvariantRedRed, "red node %v has a red parent %v", c.child.item, n.item)
+		}
+	}
+
+	v.path = append(v.path, 'L')
+	lh, err := v.check(n.left)
+	if err != nil {
+		return 0, err
+	}
+	v.path = v.path[:len(v.path)-1]
+
+	if v.prev == nil {
+		v.first = n
+	} else if !v.root.inOrder(v.prev.item, n.item) {
+		return 0, v.errorf(InvariantOrder, "node %v follows %v", n.item, v.prev.item)
+	}
+	v.prev = n
+	v.count++
+
+	v.path = append(v.path, 'R')
+	rh, err := v.check(n.right)
+	if err != nil {
+		return 0, err
+	}
+	v.path = v.path[:len(v.path)-1]
+
+	if lh != rh {
+		return 0, v.errorf(InvariantBlackHeight, "node %v has black height %d on the left and %d on the right", n.item, lh, rh)
+	}
+	if want := getSize(n.left) + getSize(n.right) + 1; n.size != want {
+		return 0, v.errorf(InvariantSize, "node %v has size %d, want %d", n.item, n.size, want)
+	}
+	if n.color == black {
+		lh++
+	}
+	return lh, nil
+}
@@ -0,0 +1,81 @@
+package rbtree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	s := NewSet[int]()
+	testAssert(t, s.Validate() == nil, "empty")
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 2000; i++ {
+		if r.Intn(3) == 0 {
+			s.DeleteWithKey(r.Intn(500))
+		} else {
+			s.Insert(r.Intn(500))
+		}
+		if err := s.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewMultiMap[int, int]()
+	for i := 0; i < 10; i++ {
+		m.Insert(i%3, i)
+	}
+	testAssert(t, m.Validate() == nil, "multimap")
+}
+
+// Corrupt a valid tree in various ways and check that Validate reports
+// the right invariant.
+func TestValidateErrors(t *testing.T) {
+	newTree := func() *Set[int] {
+		s := NewSet[int]()
+		for i := 0; i < 15; i++ {
+			s.Insert(i)
+		}
+		return s
+	}
+	for _, test := range []struct {
+		corrupt func(s *Set[int])
+		want    Invariant
+		path    string
+	}{
+		{func(s *Set[int]) { s.root.color = red }, InvariantRootBlack, ""},
+		// Reported at the next node in order, which is out of order with it.
+		{func(s *Set[int]) { s.root.left.left.item = 100 }, InvariantOrder, "L"},
+		{func(s *Set[int]) { s.root.left.parent = s.root.right }, InvariantParent, "L"},
+		{func(s *Set[int]) { s.root.right.size++ }, InvariantSize, "R"},
+		{func(s *Set[int]) { s.count-- }, InvariantCount, ""},
+		{func(s *Set[int]) { s.maxNode = s.root }, InvariantMinMax, ""},
+		{func(s *Set[int]) { s.root.left.color = tombstone }, InvariantColor, "L"},
+		{func(s *Set[int]) {
+			n := s.minNode
+			n.color = red
+			n.parent.color = red
+		}, InvariantRedRed, "LL"},
+		{func(s *Set[int]) {
+			// Blacken a red leaf.
+			for n := s.root; n != nil; n = n.right {
+				if n.color == red && n.left == nil && n.right == nil {
+					n.color = black
+					return
+				}
+			}
+			t.Fatal("no red leaf on the right spine")
+		}, InvariantBlackHeight, ""},
+	} {
+		s := newTree()
+		test.corrupt(s)
+		var verr *ValidationError
+		err := s.Validate()
+		if !errors.As(err, &verr) || verr.Invariant != test.want {
+			t.Fatalf("got %v, want a %s violation", err, test.want)
+		}
+		if test.want != InvariantBlackHeight && verr.Path != test.path {
+			t.Fatalf("%v: got path %q, want %q", err, verr.Path, test.path)
+		}
+	}
+}